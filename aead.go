@@ -13,7 +13,22 @@ const (
 	//BlockSize
 )
 
-// TODO: Section 3.1 says: "The number of processed plaintext and associated data blocks protected by the encryption algorithm is limited to a total of 2^64 blocks per key, which corresponds to 2^67 bytes (for Ascon-128, Ascon-80pq) or 2^68 bytes (for Ascon-128a)."
+var (
+	// ErrKeySize is returned when a key has the wrong length.
+	ErrKeySize = errors.New("ascon: wrong key size")
+	// ErrNonceSize is returned when a nonce has the wrong length.
+	ErrNonceSize = errors.New("ascon: wrong nonce size")
+	// ErrOpen is returned when a ciphertext fails to authenticate.
+	ErrOpen = errors.New("ascon: decryption failed")
+	// ErrKeyDestroyed is returned when a destroyed key is used.
+	ErrKeyDestroyed = errors.New("ascon: use of destroyed key")
+	// ErrBitLength is returned when a bit length is negative
+	// or does not match the length of its byte slice.
+	ErrBitLength = errors.New("ascon: bad bit length")
+)
+
+// Section 3.1 says: "The number of processed plaintext and associated data blocks protected by the encryption algorithm is limited to a total of 2^64 blocks per key, which corresponds to 2^67 bytes (for Ascon-128, Ascon-80pq) or 2^68 bytes (for Ascon-128a)."
+// AEAD128 does not keep track of this; see LimitedAEAD128.
 
 // The differences from the original specification of Ascon are
 // * bytes are little-endian instead of big endian
@@ -24,23 +39,86 @@ const (
 // AEAD128 provides an implementation of Ascon-AEAD128 from NIST.SP.800-232.
 // It implements the crypto/cipher.AEAD interface.
 type AEAD128 struct {
-	// TODO: k0, k1 uint64?
-	key [16]byte
+	// the key, decoded into lanes
+	k0, k1 uint64
+
+	destroyed bool
 }
 
+// NewAEAD128 returns an AEAD128 with the given key.
+// It returns ErrKeySize if the key is not KeySize bytes long.
 func NewAEAD128(key []byte) (*AEAD128, error) {
 	a := new(AEAD128)
-	a.SetKey(key)
+	if err := a.TrySetKey(key); err != nil {
+		return nil, err
+	}
 	return a, nil
 }
 
 // Sets the key to a new value.
+// Panics if the key is not KeySize bytes long.
 // This method is not safe for concurrent use with other methods.
 func (a *AEAD128) SetKey(key []byte) {
+	if err := a.TrySetKey(key); err != nil {
+		panic(err)
+	}
+}
+
+// TrySetKey is like SetKey, but returns ErrKeySize instead of panicking.
+func (a *AEAD128) TrySetKey(key []byte) error {
 	if len(key) != KeySize {
-		panic("ascon: wrong key size")
+		return ErrKeySize
+	}
+	a.k0 = le64dec(key[0:])
+	a.k1 = le64dec(key[8:])
+	a.destroyed = false
+	return nil
+}
+
+// Destroy erases the key from memory.
+// Afterwards, Seal and Open panic until a new key is set with SetKey.
+// This method is not safe for concurrent use with other methods.
+func (a *AEAD128) Destroy() {
+	a.k0 = 0
+	a.k1 = 0
+	a.destroyed = true
+}
+
+// appendKey appends the key bytes to b.
+func (a *AEAD128) appendKey(b []byte) []byte {
+	b = le64append(b, a.k0)
+	return le64append(b, a.k1)
+}
+
+func (a *AEAD128) checkDestroyed() {
+	if a.destroyed {
+		panic(ErrKeyDestroyed)
+	}
+}
+
+// TrySeal is like Seal, but returns an error instead of panicking
+// if the nonce is the wrong size or the key has been destroyed.
+// In that case dst is returned unchanged.
+func (a *AEAD128) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if a.destroyed {
+		return dst, ErrKeyDestroyed
+	}
+	if len(nonce) != NonceSize {
+		return dst, ErrNonceSize
+	}
+	return a.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// TryOpen is like Open, but returns an error instead of panicking
+// if the nonce is the wrong size or the key has been destroyed.
+func (a *AEAD128) TryOpen(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if a.destroyed {
+		return dst, ErrKeyDestroyed
+	}
+	if len(nonce) != NonceSize {
+		return dst, ErrNonceSize
 	}
-	copy(a.key[:], key)
+	return a.Open(dst, nonce, ciphertext, additionalData)
 }
 
 func (*AEAD128) NonceSize() int { return NonceSize }
@@ -49,65 +127,134 @@ func (*AEAD128) Overhead() int  { return TagSize }
 // Seal encrypts and authenticates a plaintext
 // and appends ciphertext to dst, returning the appended slice.
 func (a *AEAD128) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	var s state
+	return a.seal(&s, dst, nonce, plaintext, 0, additionalData, 0, 8*TagSize)
+}
+
+// SealBits is like Seal, but only the first ptBits bits of plaintext
+// and the first adBits bits of additionalData are used,
+// and the tag is truncated to its first tagBits bits.
+// Bits are numbered from the least significant bit of each byte,
+// as in NIST.SP.800-232.
+//
+// The ciphertext takes up (ptBits+7)/8 bytes, followed by (tagBits+7)/8 bytes of tag;
+// any unused bits in the last byte of either are zero.
+// Panics with ErrBitLength if either bit length is negative or
+// longer than its slice, or if tagBits is not between MinTagBits and 8*TagSize.
+func (a *AEAD128) SealBits(dst, nonce, plaintext []byte, ptBits int, additionalData []byte, adBits int, tagBits int) []byte {
+	if !bitsOK(plaintext, ptBits) || !bitsOK(additionalData, adBits) || !tagBitsOK(tagBits) {
+		panic(ErrBitLength)
+	}
+	var s state
+	return a.seal(&s, dst, nonce,
+		plaintext[:(ptBits+7)/8], uint(ptBits%8),
+		additionalData[:(adBits+7)/8], uint(adBits%8),
+		uint(tagBits))
+}
+
+// MinTagBits is the shortest tag accepted by SealBits and OpenBits.
+// Truncated tags are weaker: a forgery succeeds with probability 2^-tagBits.
+const MinTagBits = 32
+
+// bitsOK reports whether nbits is a valid bit length for b.
+func bitsOK(b []byte, nbits int) bool {
+	return nbits >= 0 && (nbits+7)/8 <= len(b)
+}
+
+// tagBitsOK reports whether tagBits is a valid truncated tag length.
+func tagBitsOK(tagBits int) bool {
+	return MinTagBits <= tagBits && tagBits <= 8*TagSize
+}
+
+// computeTag finalizes s, which has absorbed the message, into the tag.
+// If tagBits is less than 8*TagSize, the tag is truncated to (tagBits+7)/8 bytes
+// and any unused bits of its last byte are zeroed.
+func (s *state) computeTag(k0, k1 uint64, tag *[TagSize]byte, tagBits uint) []byte {
+	const A uint = 12
+	// mix the key in again
+	s[2] ^= k0
+	s[3] ^= k1
+
+	// Finalize
+	s.rounds(A)
+
+	le64enc(tag[0:], s[3]^k0)
+	le64enc(tag[8:], s[4]^k1)
+	t := tag[:(tagBits+7)/8]
+	if r := tagBits % 8; r != 0 {
+		t[len(t)-1] &= 1<<r - 1
+	}
+	return t
+}
+
+// seal implements Seal, using s as scratch space.
+// s is wiped before returning.
+//
+// If ptBits or adBits is not zero, only that many bits
+// of the last byte of plaintext or additionalData are used.
+// The tag is truncated to tagBits bits.
+func (a *AEAD128) seal(s *state, dst, nonce, plaintext []byte, ptBits uint, additionalData []byte, adBits uint, tagBits uint) []byte {
+	a.checkDestroyed()
 	if len(nonce) != NonceSize {
 		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
 	}
 
 	// Initialize
 	// IV || key || nonce
-	var s state
 	const A, B uint = 12, 8
-	s.initAEADle(a.key[:], 128, uint8(A), uint8(B), nonce)
+	k0 := a.k0
+	k1 := a.k1
+	s.initAEADle(k0, k1, 128, uint8(A), uint8(B), nonce)
 	//log.Printf("%x\n", &s)
 
 	// mix the key in again
-	k0 := le64dec(a.key[0:])
-	k1 := le64dec(a.key[8:])
 	s[3] ^= k0
 	s[4] ^= k1
 
 	// Absorb additionalData
-	s.mixAdditionalData(additionalData, B)
+	s.mixAdditionalData(additionalData, adBits, B)
 	// domain-separation constant
 	s[4] ^= 0x80 << 56
 
 	// allocate space
+	tagLen := int(tagBits+7) / 8
 	dstLen := len(dst)
-	dst = append(dst, make([]byte, len(plaintext)+TagSize)...)
+	dst = extend(dst, len(plaintext)+tagLen)
 
 	// Duplex plaintext/ciphertext
-	c := s.encrypt(plaintext, dst[dstLen:], B)
-
-	// mix the key in again
-	s[2] ^= k0
-	s[3] ^= k1
-
-	// Finalize
-	s.rounds(A)
+	// The encryption may scribble over the space reserved for the tag,
+	// so there must be room for a whole one.
+	c := dst[dstLen:]
+	if tagLen < TagSize {
+		c = make([]byte, len(plaintext)+TagSize)
+	}
+	s.encrypt(plaintext, ptBits, c, B)
+	if tagLen < TagSize {
+		copy(dst[dstLen:], c[:len(plaintext)])
+	}
 
 	// Append tag
-	t0 := s[3] ^ k0
-	t1 := s[4] ^ k1
-	le64enc(c[0:], t0)
-	le64enc(c[8:], t1)
+	var tag [TagSize]byte
+	copy(dst[dstLen+len(plaintext):], s.computeTag(k0, k1, &tag, tagBits))
 
+	s.wipe()
 	return dst
 }
 
-func (s *state) initAEADle(key []byte, blockSize, A, B uint8, nonce []byte) {
-	if len(key) != KeySize {
-		panic("invalid key length")
-	}
-	s[0] = 1 + uint64(A)<<16 + uint64(B)<<20 + uint64(byte(len(key)*8))<<24 + uint64(blockSize/8)<<40
-	s[1] = le64dec(key[0:])
-	s[2] = le64dec(key[8:])
+func (s *state) initAEADle(k0, k1 uint64, blockSize, A, B uint8, nonce []byte) {
+	const keyBits = KeySize * 8
+	s[0] = 1 + uint64(A)<<16 + uint64(B)<<20 + uint64(byte(keyBits))<<24 + uint64(blockSize/8)<<40
+	s[1] = k0
+	s[2] = k1
 	s[3] = le64dec(nonce[0:])
 	s[4] = le64dec(nonce[8:])
 	//log.Printf("%x\n", &s)
 	s.rounds(uint(A))
 }
 
-func (s *state) mixAdditionalData(additionalData []byte, B uint) {
+// mixAdditionalData absorbs additionalData.
+// If partialBits is not zero, only that many bits of its last byte are used.
+func (s *state) mixAdditionalData(additionalData []byte, partialBits uint, B uint) {
 	ad := additionalData
 	if len(ad) <= 0 {
 		// If there is no additional data, nothing is added
@@ -115,32 +262,41 @@ func (s *state) mixAdditionalData(additionalData []byte, B uint) {
 		return
 	}
 
-	for len(ad) >= 16 {
+	for len(ad) > 16 || len(ad) == 16 && partialBits == 0 {
 		s[0] ^= le64dec(ad)
 		s[1] ^= le64dec(ad[8:])
 		ad = ad[16:]
 		s.rounds(B)
 	}
 
-	// last chunk
-	if len(ad) > 0 {
-		var buf [16]byte
-		n := copy(buf[:], ad)
-		buf[n] = 1 // Pad
-		s[0] ^= le64dec(buf[:])
-		s[1] ^= le64dec(buf[8:])
-		s.rounds(B)
-	} else {
-		// Pad
-		s[0] ^= 1
-		s.rounds(B)
+	// last chunk, which may be empty
+	var buf [16]byte
+	padBlock(&buf, ad, partialBits)
+	s[0] ^= le64dec(buf[:])
+	s[1] ^= le64dec(buf[8:])
+	s.rounds(B)
+}
+
+// padBlock copies the final partial block p into buf
+// and appends a 1 bit followed by zeroes.
+// If partialBits is not zero, only that many bits of the last byte of p are used,
+// and the padding starts in that byte.
+func padBlock(buf *[16]byte, p []byte, partialBits uint) {
+	n := copy(buf[:], p)
+	if partialBits != 0 {
+		n--
+		buf[n] &= 1<<partialBits - 1
 	}
+	buf[n] |= 1 << partialBits // Pad
 }
 
-func (s *state) encrypt(plaintext, dst []byte, B uint) []byte {
+// encrypt duplexes plaintext into dst and returns the remainder of dst.
+// If partialBits is not zero, only that many bits of the last byte of plaintext are used,
+// and the remaining bits of that ciphertext byte are zeroed.
+func (s *state) encrypt(plaintext []byte, partialBits uint, dst []byte, B uint) []byte {
 	p := plaintext
 	c := dst
-	for len(p) >= 16 {
+	for len(p) > 16 || len(p) == 16 && partialBits == 0 {
 		s[0] ^= le64dec(p)
 		s[1] ^= le64dec(p[8:])
 		le64enc(c[0:], s[0])
@@ -151,8 +307,8 @@ func (s *state) encrypt(plaintext, dst []byte, B uint) []byte {
 	}
 	if len(p) > 0 {
 		var buf [16]byte
-		n := copy(buf[:], p)
-		buf[n] = 1 // Pad
+		n := len(p)
+		padBlock(&buf, p, partialBits)
 		s[0] ^= le64dec(buf[:])
 		s[1] ^= le64dec(buf[8:])
 		// may write up to 15 too many bytes
@@ -160,6 +316,10 @@ func (s *state) encrypt(plaintext, dst []byte, B uint) []byte {
 		// for the tag
 		le64enc(c, s[0])
 		le64enc(c[8:], s[1])
+		if partialBits != 0 {
+			// clear the padding and keystream bits
+			c[n-1] &= 1<<partialBits - 1
+		}
 		c = c[n:]
 	} else {
 		// Pad
@@ -169,74 +329,96 @@ func (s *state) encrypt(plaintext, dst []byte, B uint) []byte {
 	return c
 }
 
-var fail = errors.New("ascon: decryption failed")
-
 func (a *AEAD128) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	var s state
+	return a.open(&s, dst, nonce, ciphertext, 0, additionalData, 0, 8*TagSize)
+}
+
+// OpenBits is like Open, but for messages sealed with SealBits.
+// The ciphertext must consist of (ctBits+7)/8 bytes of ciphertext
+// followed by (tagBits+7)/8 bytes of tag,
+// and only the first adBits bits of additionalData are used.
+// Unused bits in the last byte of the ciphertext or tag are ignored,
+// and those of the plaintext are zero.
+// It returns ErrBitLength if either bit length doesn't fit its slice,
+// or if tagBits is not between MinTagBits and 8*TagSize.
+func (a *AEAD128) OpenBits(dst, nonce, ciphertext []byte, ctBits int, additionalData []byte, adBits int, tagBits int) ([]byte, error) {
+	if ctBits < 0 || !tagBitsOK(tagBits) || len(ciphertext) != (ctBits+7)/8+(tagBits+7)/8 || !bitsOK(additionalData, adBits) {
+		return dst, ErrBitLength
+	}
+	var s state
+	return a.open(&s, dst, nonce,
+		ciphertext, uint(ctBits%8),
+		additionalData[:(adBits+7)/8], uint(adBits%8),
+		uint(tagBits))
+}
+
+// open implements Open, using s as scratch space.
+// s is wiped before returning.
+//
+// If ctBits or adBits is not zero, only that many bits
+// of the last byte of the ciphertext (excluding the tag) or additionalData are used.
+// The tag is truncated to tagBits bits.
+func (a *AEAD128) open(s *state, dst, nonce, ciphertext []byte, ctBits uint, additionalData []byte, adBits uint, tagBits uint) ([]byte, error) {
+	a.checkDestroyed()
 	if len(nonce) != NonceSize {
 		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
-		// return fail?
 	}
 
-	if len(ciphertext) < TagSize {
-		return dst, fail
+	tagLen := int(tagBits+7) / 8
+	if len(ciphertext) < tagLen {
+		return dst, ErrOpen
 	}
-	plaintextSize := len(ciphertext) - TagSize
+	plaintextSize := len(ciphertext) - tagLen
 	expectedTag := ciphertext[plaintextSize:]
 	ciphertext = ciphertext[0:plaintextSize]
 
 	dstLen := len(dst)
-	dst = append(dst, make([]byte, plaintextSize)...)
+	dst = extend(dst, plaintextSize)
 
 	// Initialize
 	// IV || key || nonce
-	var s state
 	const A, B uint = 12, 8
-	s.initAEADle(a.key[:], 128, uint8(A), uint8(B), nonce)
+	k0 := a.k0
+	k1 := a.k1
+	s.initAEADle(k0, k1, 128, uint8(A), uint8(B), nonce)
 	//log.Printf("%x\n", &s)
 
 	// mix the key in again
-	k0 := le64dec(a.key[0:])
-	k1 := le64dec(a.key[8:])
 	s[3] ^= k0
 	s[4] ^= k1
 
 	// Absorb additionalData
-	s.mixAdditionalData(additionalData, B)
+	s.mixAdditionalData(additionalData, adBits, B)
 	// domain-separation constant
 	s[4] ^= 0x80 << 56
 
 	// Duplex plaintext/ciphertext
-	s.decrypt(ciphertext, dst[dstLen:], B)
-
-	// mix the key in again
-	s[2] ^= k0
-	s[3] ^= k1
-
-	// Finalize
-	s.rounds(A)
+	s.decrypt(ciphertext, ctBits, dst[dstLen:], B)
 
 	// Compute tag
-	t0 := s[3] ^ k0
-	t1 := s[4] ^ k1
-	// Check tag in constant time
-	t0 ^= le64dec(expectedTag[0:])
-	t1 ^= le64dec(expectedTag[8:])
-	t := uint32(t0>>32) | uint32(t0)
-	t |= uint32(t1>>32) | uint32(t1)
-	if subtle.ConstantTimeEq(int32(t), 0) == 0 {
-		//t0 = s[3] ^ k0
-		//t1 = s[4] ^ k1
-		//return dst, fmt.Errorf("tag mismatch: got %016x %016x, want %x", t0, t1, expectedTag)
-		return dst, fail
+	var tag, want [TagSize]byte
+	t := s.computeTag(k0, k1, &tag, tagBits)
+	s.wipe()
+	// Check tag in constant time, ignoring unused bits
+	w := want[:copy(want[:tagLen], expectedTag)]
+	if r := tagBits % 8; r != 0 {
+		w[tagLen-1] &= 1<<r - 1
+	}
+	if subtle.ConstantTimeCompare(t, w) != 1 {
+		return dst, ErrOpen
 	}
 
 	return dst, nil
 }
 
-func (s *state) decrypt(ciphertext, dst []byte, B uint) {
+// decrypt duplexes ciphertext into dst.
+// If partialBits is not zero, only that many bits of the last byte of ciphertext are used,
+// and the remaining bits of that plaintext byte are zeroed.
+func (s *state) decrypt(ciphertext []byte, partialBits uint, dst []byte, B uint) {
 	c := ciphertext
 	p := dst
-	for len(c) >= 16 {
+	for len(c) > 16 || len(c) == 16 && partialBits == 0 {
 		x := le64dec(c)
 		y := le64dec(c[8:])
 		le64enc(p[0:], x^s[0])
@@ -248,7 +430,7 @@ func (s *state) decrypt(ciphertext, dst []byte, B uint) {
 		s.rounds(B)
 	}
 	si := 0
-	if len(c) >= 8 {
+	if len(c) > 8 || len(c) == 8 && partialBits == 0 {
 		x := le64dec(c)
 		le64enc(p, x^s[0])
 		s[0] = x
@@ -260,12 +442,17 @@ func (s *state) decrypt(ciphertext, dst []byte, B uint) {
 		for i := range p {
 			p[i] = c[i] ^ byte(s[si]>>(i*8))
 		}
+		pad := uint(len(c)) * 8
+		if partialBits != 0 {
+			pad -= 8 - partialBits
+			p[len(p)-1] &= 1<<partialBits - 1
+		}
 
 		var x uint64
 		for i := range p {
 			x |= uint64(p[i]) << (i * 8)
 		}
-		x |= 1 << (len(c) * 8) // Pad
+		x |= 1 << pad // Pad
 
 		s[si] ^= x
 	} else {
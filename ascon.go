@@ -85,3 +85,13 @@ func roundGeneric(s *state, numRounds uint) {
 	s[3] = x3
 	s[4] = x4
 }
+
+// wipe zeroes the state, so that secrets derived from the key
+// don't linger in memory.
+// It is not inlined so that the compiler can't remove the stores
+// when s is about to go out of scope.
+//
+//go:noinline
+func (s *state) wipe() {
+	*s = state{}
+}
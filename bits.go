@@ -7,6 +7,14 @@ package ascon
 
 import "encoding/binary"
 
+func be32dec(b []byte) uint32 {
+	return binary.BigEndian.Uint32(b)
+}
+
+func be32append(b []byte, x uint32) []byte {
+	return append(b, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+}
+
 func be64dec(b []byte) uint64 {
 	return binary.BigEndian.Uint64(b)
 }
@@ -30,3 +38,15 @@ func le64enc(b []byte, x uint64) {
 func le64append(b []byte, x uint64) []byte {
 	return append(b, byte(x), byte(x>>8), byte(x>>16), byte(x>>24), byte(x>>32), byte(x>>40), byte(x>>48), byte(x>>56))
 }
+
+// extend returns b extended by n bytes.
+// Unlike append, it doesn't overwrite the extra bytes if b has room for them,
+// so that they may overlap the input of an in-place Seal or Open.
+func extend(b []byte, n int) []byte {
+	if total := len(b) + n; cap(b) >= total {
+		return b[:total]
+	}
+	new := make([]byte, len(b)+n)
+	copy(new, b)
+	return new
+}
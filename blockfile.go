@@ -0,0 +1,262 @@
+package ascon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrRollback is returned by BlockFile when a block doesn't have
+// the version recorded for it, for example because it has been
+// rolled back to an older version or erased.
+var ErrRollback = errors.New("ascon: block has been rolled back")
+
+// ReaderWriterAt is the interface implemented by the storage underlying a BlockFile,
+// such as an *os.File.
+type ReaderWriterAt interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// BlockFile provides random access to a file encrypted in fixed-size blocks,
+// each sealed separately with AEAD128.
+//
+// Block i is stored in slot i of the underlying file:
+//
+//	version uint64 (big endian)
+//	ciphertext [blockSize]byte
+//	tag [TagSize]byte
+//
+// The blocks are sealed with a subkey derived from the key and the file ID
+// with Ascon-CXOF128, so blocks can't be moved to a different file,
+// and files which share a key never share a (key, nonce) pair.
+// Block i is sealed with the nonce i || version, both big endian,
+// and i as additional data, so it can't be moved to a different position.
+// The version starts at 1 and increases each time the block is written.
+//
+// BlockFile keeps a table of the version of every block,
+// which it trusts over the contents of the file.
+// A block must have exactly the version in the table,
+// which detects rollback of individual blocks to an older version.
+// A block whose version is 0 has never been written, and reads as zeroes;
+// its slot must be missing or all zeroes.
+// Reading past the last block in the table returns io.EOF,
+// and writing past it extends the file, leaving unwritten blocks as zeroes.
+//
+// Writes take the new version from the table, never from the file,
+// so a nonce is never reused as long as the table is never rolled back.
+// To reopen a file, save Versions after each write
+// somewhere an attacker can't modify, and pass it to OpenBlockFile.
+// If the saved table might be older than the file, for example after a crash,
+// don't write to the file with it: copy the data to a new file ID instead.
+//
+// It is safe for concurrent use, but operations are serialized.
+type BlockFile struct {
+	mu        sync.Mutex
+	aead      AEAD128
+	f         ReaderWriterAt
+	blockSize int
+	versions  []uint64 // 0 means never written
+}
+
+const (
+	blockVersionSize = 8
+
+	blockFileCustomization = "Ascon-BlockFile128"
+)
+
+// NewBlockFile returns a BlockFile which stores blocks of blockSize bytes in f,
+// sealed with a subkey derived from key and fileID.
+// The file must be new: no block has been written to it yet.
+// Use OpenBlockFile to reopen an existing file.
+// The key may be shared between files with different file IDs.
+func NewBlockFile(f ReaderWriterAt, key, fileID []byte, blockSize int) (*BlockFile, error) {
+	return OpenBlockFile(f, key, fileID, blockSize, nil)
+}
+
+// OpenBlockFile is like NewBlockFile, but reopens an existing file
+// whose table of block versions, as returned by Versions, is versions.
+func OpenBlockFile(f ReaderWriterAt, key, fileID []byte, blockSize int, versions []uint64) (*BlockFile, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("ascon: bad block size %d", blockSize)
+	}
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	b := &BlockFile{
+		f:         f,
+		blockSize: blockSize,
+		versions:  append([]uint64(nil), versions...),
+	}
+	var subkey [KeySize]byte
+	kdf := newKeyedCxof(blockFileCustomization, key)
+	kdf.digest.write(fileID)
+	kdf.digest.read(subkey[:])
+	kdf.digest.wipe()
+	b.aead.SetKey(subkey[:])
+	for i := range subkey {
+		subkey[i] = 0
+	}
+	return b, nil
+}
+
+// BlockSize returns the number of plaintext bytes in each block.
+func (b *BlockFile) BlockSize() int { return b.blockSize }
+
+// Versions returns a copy of the table of block versions.
+// Element i is the version of block i, or 0 if it has never been written.
+func (b *BlockFile) Versions() []uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]uint64(nil), b.versions...)
+}
+
+// ReadAt reads len(p) bytes of plaintext starting at offset off.
+// It returns ErrOpen if a block fails to authenticate
+// and ErrRollback if a block doesn't have its recorded version.
+func (b *BlockFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("ascon: negative offset")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	block := make([]byte, b.blockSize)
+	slot := make([]byte, b.slotSize())
+	bs := int64(b.blockSize)
+	n := 0
+	for n < len(p) {
+		i := uint64(off / bs)
+		if _, err := b.readBlock(block, slot, i); err != nil {
+			return n, err
+		}
+		m := copy(p[n:], block[off%bs:])
+		n += m
+		off += int64(m)
+	}
+	return n, nil
+}
+
+// WriteAt writes len(p) bytes of plaintext starting at offset off.
+// Each block is read before it is overwritten,
+// and must authenticate and have its recorded version first.
+func (b *BlockFile) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("ascon: negative offset")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	block := make([]byte, b.blockSize)
+	slot := make([]byte, b.slotSize())
+	bs := int64(b.blockSize)
+	n := 0
+	for n < len(p) {
+		i := uint64(off / bs)
+		version, err := b.readBlock(block, slot, i)
+		if err == io.EOF {
+			for j := range block {
+				block[j] = 0
+			}
+		} else if err != nil {
+			return n, err
+		}
+		m := copy(block[off%bs:], p[n:])
+		if err := b.writeBlock(block, slot, i, version); err != nil {
+			return n, err
+		}
+		n += m
+		off += int64(m)
+	}
+	return n, nil
+}
+
+func (b *BlockFile) slotSize() int64 {
+	return blockVersionSize + int64(b.blockSize) + TagSize
+}
+
+// readBlock reads and decrypts block i into block, using slot as scratch space,
+// and returns its version from the table.
+// It returns io.EOF if the block is past the end of the table.
+// b.mu must be held.
+func (b *BlockFile) readBlock(block, slot []byte, i uint64) (uint64, error) {
+	n, err := b.f.ReadAt(slot, int64(i)*b.slotSize())
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	version := b.version(i)
+	if version == 0 {
+		// never written, so the slot must be missing or empty
+		for _, c := range slot[:n] {
+			if c != 0 {
+				return 0, ErrRollback
+			}
+		}
+		if i >= uint64(len(b.versions)) {
+			return 0, io.EOF
+		}
+		for j := range block {
+			block[j] = 0
+		}
+		return 0, nil
+	}
+	if n == 0 {
+		return 0, ErrRollback
+	}
+	if n < len(slot) {
+		// a partial slot
+		return 0, ErrOpen
+	}
+	if be64dec(slot) != version {
+		return 0, ErrRollback
+	}
+	var nonce [NonceSize]byte
+	blockNonce(&nonce, i, version)
+	if _, err := b.aead.Open(block[:0], nonce[:], slot[blockVersionSize:], b.additionalData(i)); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// writeBlock encrypts block into slot and writes it as block i,
+// whose current version is version.
+// The new version is recorded before the block is written,
+// so that it is never used twice, even if the write fails.
+// b.mu must be held.
+func (b *BlockFile) writeBlock(block, slot []byte, i, version uint64) error {
+	version++
+	if version == 0 {
+		return ErrNonceExhausted
+	}
+	b.setVersion(i, version)
+	var nonce [NonceSize]byte
+	blockNonce(&nonce, i, version)
+	be64enc(slot, version)
+	b.aead.Seal(slot[:blockVersionSize], nonce[:], block, b.additionalData(i))
+	_, err := b.f.WriteAt(slot, int64(i)*b.slotSize())
+	return err
+}
+
+func (b *BlockFile) additionalData(i uint64) []byte {
+	var ad [8]byte
+	be64enc(ad[:], i)
+	return ad[:]
+}
+
+func (b *BlockFile) version(i uint64) uint64 {
+	if i < uint64(len(b.versions)) {
+		return b.versions[i]
+	}
+	return 0
+}
+
+func (b *BlockFile) setVersion(i, version uint64) {
+	for uint64(len(b.versions)) <= i {
+		b.versions = append(b.versions, 0)
+	}
+	b.versions[i] = version
+}
+
+func blockNonce(nonce *[NonceSize]byte, i, version uint64) {
+	be64enc(nonce[0:], i)
+	be64enc(nonce[8:], version)
+}
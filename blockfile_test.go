@@ -0,0 +1,266 @@
+package ascon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// memFile is an in-memory ReaderWriterAt.
+type memFile struct{ b []byte }
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(f.b) {
+		f.b = append(f.b, make([]byte, end-len(f.b))...)
+	}
+	return copy(f.b[off:], p), nil
+}
+
+func TestBlockFile(t *testing.T) {
+	const blockSize = 16
+	f := new(memFile)
+	b, err := NewBlockFile(f, mkPattern(KeySize, 0x00), []byte("file 1"), blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []byte
+	writes := []struct{ off, n int }{
+		{0, 16}, {5, 3}, {14, 20}, {60, 1}, {0, 70}, {33, 0}, {40, 16},
+	}
+	for k, w := range writes {
+		p := mkPattern(w.n, byte(k*0x10))
+		if n, err := b.WriteAt(p, int64(w.off)); n != w.n || err != nil {
+			t.Fatalf("WriteAt(%d, %d) = %d, %v", w.off, w.n, n, err)
+		}
+		if end := w.off + w.n; end > len(want) {
+			want = append(want, make([]byte, end-len(want))...)
+		}
+		copy(want[w.off:], p)
+	}
+	// the file is always a whole number of blocks
+	if len(want)%blockSize != 0 {
+		want = append(want, make([]byte, blockSize-len(want)%blockSize)...)
+	}
+
+	got := make([]byte, len(want))
+	if n, err := b.ReadAt(got, 0); n != len(got) || err != nil {
+		t.Fatalf("ReadAt = %d, %v", n, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %X, want %X", got, want)
+	}
+	for off := 0; off < len(want); off += 7 {
+		got := make([]byte, 9)
+		n, err := b.ReadAt(got, int64(off))
+		if off+len(got) > len(want) {
+			if err != io.EOF {
+				t.Errorf("ReadAt(%d) past the end: got error %v, want io.EOF", off, err)
+			}
+		} else if err != nil {
+			t.Errorf("ReadAt(%d): %v", off, err)
+		}
+		if !bytes.Equal(got[:n], want[off:off+n]) {
+			t.Errorf("ReadAt(%d) = %X, want %X", off, got[:n], want[off:off+n])
+		}
+	}
+
+	// the file can be reopened with its versions
+	versions := b.Versions()
+	b2, _ := OpenBlockFile(f, mkPattern(KeySize, 0x00), []byte("file 1"), blockSize, versions)
+	if _, err := b2.ReadAt(got, 0); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("ReadAt after OpenBlockFile = %X, %v", got, err)
+	}
+	// but not with a different file ID
+	b3, _ := OpenBlockFile(f, mkPattern(KeySize, 0x00), []byte("file 2"), blockSize, versions)
+	if _, err := b3.ReadAt(got, 0); !errors.Is(err, ErrOpen) {
+		t.Errorf("ReadAt with the wrong file ID: got %v, want ErrOpen", err)
+	}
+}
+
+func TestBlockFileSharedKey(t *testing.T) {
+	// files with the same key and different IDs don't share nonces,
+	// so the same writes give unrelated ciphertexts
+	key := mkPattern(KeySize, 0x00)
+	f1, f2 := new(memFile), new(memFile)
+	b1, _ := NewBlockFile(f1, key, []byte("file 1"), 16)
+	b2, _ := NewBlockFile(f2, key, []byte("file 2"), 16)
+	b1.WriteAt(mkPattern(16, 0x40), 0)
+	b2.WriteAt(mkPattern(16, 0x80), 0)
+	x := make([]byte, 16)
+	for i := range x {
+		x[i] = f1.b[blockVersionSize+i] ^ f2.b[blockVersionSize+i]
+	}
+	if bytes.Equal(x, xorBytes(mkPattern(16, 0x40), mkPattern(16, 0x80))) {
+		t.Error("files with different IDs use the same keystream")
+	}
+	if _, err := NewBlockFile(f1, key[:8], nil, 16); err != ErrKeySize {
+		t.Errorf("NewBlockFile with a short key: got %v, want ErrKeySize", err)
+	}
+}
+
+func xorBytes(a, b []byte) []byte {
+	x := make([]byte, len(a))
+	for i := range x {
+		x[i] = a[i] ^ b[i]
+	}
+	return x
+}
+
+func TestBlockFileGolden(t *testing.T) {
+	f := new(memFile)
+	b, _ := NewBlockFile(f, mkPattern(KeySize, 0x00), []byte("file 1"), 16)
+	b.WriteAt(mkPattern(16, 0x40), 16)
+	b.WriteAt(mkPattern(1, 0x80), 20)
+	const want = "" +
+		// block 0 was never written
+		"00000000000000000000000000000000000000000000000000000000000000000000000000000000" +
+		// block 1 was written twice
+		"0000000000000002" + "875CBC826C3C82CDD44FDA5C4DD24F77" + "93E6DB908F5C6DEAAD5A9E57C63C7BBE"
+	if got := fmt.Sprintf("%X", f.b); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// the same block, sealed as documented
+	x, _ := NewCxof128(blockFileCustomization)
+	x.Write([]byte{KeySize * 8, 0, 0, 0, 0, 0, 0, 0})
+	x.Write(mkPattern(KeySize, 0x00))
+	x.Write([]byte("file 1"))
+	subkey := make([]byte, KeySize)
+	x.Read(subkey)
+	a, _ := NewAEAD128(subkey)
+	pt := mkPattern(16, 0x40)
+	copy(pt[4:], mkPattern(1, 0x80))
+	nonce := unhex("00000000000000010000000000000002")
+	ct := a.Seal(nil, nonce, pt, unhex("0000000000000001"))
+	if got := fmt.Sprintf("%X", f.b[40+8:]); got != fmt.Sprintf("%X", ct) {
+		t.Errorf("block 1 = %s, definition gives %X", got, ct)
+	}
+}
+
+func TestBlockFileTamper(t *testing.T) {
+	const blockSize = 16
+	key := mkPattern(KeySize, 0x00)
+	slot := blockVersionSize + blockSize + TagSize
+	f := new(memFile)
+	b, _ := NewBlockFile(f, key, []byte("file 1"), blockSize)
+	b.WriteAt(mkPattern(3*blockSize, 0x40), 0)
+	buf := make([]byte, 3*blockSize)
+
+	// swapping blocks
+	saved := append([]byte(nil), f.b...)
+	copy(f.b[0:slot], saved[slot:2*slot])
+	copy(f.b[slot:2*slot], saved[0:slot])
+	b2, _ := OpenBlockFile(f, key, []byte("file 1"), blockSize, b.Versions())
+	if _, err := b2.ReadAt(buf, 0); !errors.Is(err, ErrOpen) {
+		t.Errorf("ReadAt with swapped blocks: got %v, want ErrOpen", err)
+	}
+	copy(f.b, saved)
+
+	// a truncated slot
+	f.b = f.b[:len(f.b)-1]
+	if _, err := b2.ReadAt(buf, 0); !errors.Is(err, ErrOpen) {
+		t.Errorf("ReadAt with truncated file: got %v, want ErrOpen", err)
+	}
+	f.b = append(f.b, saved[len(saved)-1])
+
+	// rolling back a block
+	if _, err := b.WriteAt([]byte("new"), blockSize); err != nil {
+		t.Fatal(err)
+	}
+	versions := b.Versions()
+	if want := []uint64{1, 2, 1}; fmt.Sprint(versions) != fmt.Sprint(want) {
+		t.Errorf("Versions() = %v, want %v", versions, want)
+	}
+	copy(f.b[slot:2*slot], saved[slot:2*slot])
+	if _, err := b.ReadAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt of old block: got %v, want ErrRollback", err)
+	}
+	if _, err := b.WriteAt([]byte("x"), blockSize); !errors.Is(err, ErrRollback) {
+		t.Errorf("WriteAt to old block: got %v, want ErrRollback", err)
+	}
+	// reopening with the table still catches it
+	b3, _ := OpenBlockFile(f, key, []byte("file 1"), blockSize, versions)
+	if _, err := b3.ReadAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt after OpenBlockFile: got %v, want ErrRollback", err)
+	}
+	if _, err := b3.WriteAt(make([]byte, 3*blockSize), 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("WriteAt after OpenBlockFile: got %v, want ErrRollback", err)
+	}
+
+	// without the table, none of the blocks are known,
+	// so they can't be read or overwritten
+	b4, _ := NewBlockFile(f, key, []byte("file 1"), blockSize)
+	if _, err := b4.ReadAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt without versions: got %v, want ErrRollback", err)
+	}
+	if _, err := b4.WriteAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("WriteAt without versions: got %v, want ErrRollback", err)
+	}
+	if v := b4.Versions(); len(v) != 0 {
+		t.Errorf("failed WriteAt changed the versions to %v", v)
+	}
+
+	// erasing a block
+	for i := slot; i < 2*slot; i++ {
+		f.b[i] = 0
+	}
+	if _, err := b3.ReadAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt of erased block: got %v, want ErrRollback", err)
+	}
+	// or removing it
+	f.b = f.b[:slot]
+	if _, err := b3.ReadAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt of removed block: got %v, want ErrRollback", err)
+	}
+}
+
+func TestBlockFileUnwritten(t *testing.T) {
+	const blockSize = 16
+	key := mkPattern(KeySize, 0x00)
+	slot := blockVersionSize + blockSize + TagSize
+	f := new(memFile)
+	b, _ := NewBlockFile(f, key, []byte("file 1"), blockSize)
+	b.WriteAt(mkPattern(blockSize, 0x40), 2*blockSize)
+	buf := make([]byte, blockSize)
+	if _, err := b.ReadAt(buf, 0); err != nil || !bytes.Equal(buf, make([]byte, blockSize)) {
+		t.Errorf("ReadAt of unwritten block = %X, %v", buf, err)
+	}
+
+	// replacing an unwritten block
+	copy(f.b[0:slot], f.b[2*slot:3*slot])
+	if _, err := b.ReadAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt of replaced unwritten block: got %v, want ErrRollback", err)
+	}
+	if _, err := b.WriteAt(buf, 0); !errors.Is(err, ErrRollback) {
+		t.Errorf("WriteAt to replaced unwritten block: got %v, want ErrRollback", err)
+	}
+	for i := 0; i < slot; i++ {
+		f.b[i] = 0
+	}
+
+	// adding a block past the end
+	f.b = append(f.b, f.b[2*slot:3*slot]...)
+	if _, err := b.ReadAt(buf, 3*blockSize); !errors.Is(err, ErrRollback) {
+		t.Errorf("ReadAt of added block: got %v, want ErrRollback", err)
+	}
+	if _, err := b.WriteAt(buf, 3*blockSize); !errors.Is(err, ErrRollback) {
+		t.Errorf("WriteAt to added block: got %v, want ErrRollback", err)
+	}
+	f.b = f.b[:3*slot]
+	if _, err := b.ReadAt(buf, 3*blockSize); err != io.EOF {
+		t.Errorf("ReadAt past the end: got %v, want io.EOF", err)
+	}
+}
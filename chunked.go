@@ -0,0 +1,345 @@
+package ascon
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Chunked encryption splits a large plaintext into fixed-size chunks
+// and seals each one separately with AEAD128,
+// so that chunks can be encrypted and decrypted in parallel
+// and any chunk can be decrypted on its own.
+//
+// Format:
+//
+//	header  [ChunkHeaderSize]byte
+//	chunks  [n][chunkSize + TagSize]byte, except the last, which may be shorter
+//
+// The header is:
+//
+//	version     byte (1)
+//	chunkSize   uint32 (big endian)
+//	noncePrefix [8]byte
+//
+// Chunk i is sealed with the nonce noncePrefix || i || final,
+// where i is a 7-byte big-endian integer and final is 1 for the last chunk and 0 otherwise,
+// and the header as additional data.
+// The final flag means that a ciphertext which has been truncated
+// at a chunk boundary, or extended past its end, fails to decrypt.
+// An empty plaintext is sealed as a single empty final chunk.
+//
+// The nonce prefix is random, so a key should not be used
+// for more than about 2^32 files.
+
+const (
+	// ChunkHeaderSize is the length of the header of a chunked ciphertext.
+	ChunkHeaderSize = 1 + 4 + chunkNoncePrefixSize
+
+	// DefaultChunkSize is the chunk size used if ChunkOptions.ChunkSize is zero.
+	DefaultChunkSize = 64 << 10
+
+	// MaxChunkSize is the largest allowed chunk size.
+	MaxChunkSize = 16 << 20
+
+	chunkVersion         = 1
+	chunkNoncePrefixSize = 8
+	maxChunks            = 1 << 56
+)
+
+var errChunkHeader = errors.New("ascon: invalid chunked ciphertext header")
+
+// ChunkOptions configures EncryptChunked and DecryptChunked.
+// A nil *ChunkOptions is equivalent to the zero value.
+type ChunkOptions struct {
+	// ChunkSize is the number of plaintext bytes in each chunk.
+	// If zero, DefaultChunkSize is used.
+	// It is ignored by DecryptChunked, which reads it from the header.
+	ChunkSize int
+
+	// Workers is the number of goroutines which seal or open chunks.
+	// If zero, runtime.GOMAXPROCS(0) is used.
+	// The output does not depend on the number of workers.
+	Workers int
+
+	// Rand is the source of the nonce prefix.
+	// If nil, crypto/rand.Reader is used.
+	Rand io.Reader
+}
+
+func (o *ChunkOptions) workers() int {
+	if o != nil && o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// EncryptChunked reads a plaintext from src
+// and writes it to dst as a chunked ciphertext, sealed with key.
+func EncryptChunked(dst io.Writer, src io.Reader, key []byte, opts *ChunkOptions) error {
+	a, err := NewAEAD128(key)
+	if err != nil {
+		return err
+	}
+	chunkSize := DefaultChunkSize
+	rd := rand.Reader
+	if opts != nil {
+		if opts.ChunkSize != 0 {
+			chunkSize = opts.ChunkSize
+		}
+		if opts.Rand != nil {
+			rd = opts.Rand
+		}
+	}
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return fmt.Errorf("ascon: bad chunk size %d", chunkSize)
+	}
+
+	header := make([]byte, 0, ChunkHeaderSize)
+	header = append(header, chunkVersion)
+	header = be32append(header, uint32(chunkSize))
+	header = header[:ChunkHeaderSize]
+	if _, err := io.ReadFull(rd, header[5:]); err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	p := newChunkPipeline(chunkSize, chunkSize+TagSize, opts.workers())
+	return p.run(dst, src, func(c *chunkJob) {
+		var nonce [NonceSize]byte
+		chunkNonce(&nonce, header, c.index, c.final)
+		c.out = a.Seal(c.out[:0], nonce[:], c.in, header)
+	})
+}
+
+// DecryptChunked reads a chunked ciphertext from src,
+// opens it with key, and writes the plaintext to dst.
+//
+// Chunks are written to dst as they are authenticated,
+// so if an error is returned, some of the plaintext may already have been written,
+// and it must not be treated as complete.
+func DecryptChunked(dst io.Writer, src io.Reader, key []byte, opts *ChunkOptions) error {
+	a, err := NewAEAD128(key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, ChunkHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errChunkHeader
+		}
+		return err
+	}
+	chunkSize, err := parseChunkHeader(header)
+	if err != nil {
+		return err
+	}
+
+	p := newChunkPipeline(chunkSize+TagSize, chunkSize, opts.workers())
+	return p.run(dst, src, func(c *chunkJob) {
+		var nonce [NonceSize]byte
+		chunkNonce(&nonce, header, c.index, c.final)
+		c.out, c.err = a.Open(c.out[:0], nonce[:], c.in, header)
+	})
+}
+
+func parseChunkHeader(header []byte) (chunkSize int, err error) {
+	if len(header) != ChunkHeaderSize || header[0] != chunkVersion {
+		return 0, errChunkHeader
+	}
+	n := be32dec(header[1:])
+	if n == 0 || n > MaxChunkSize {
+		return 0, errChunkHeader
+	}
+	return int(n), nil
+}
+
+// chunkNonce sets nonce to the nonce for the given chunk.
+func chunkNonce(nonce *[NonceSize]byte, header []byte, index uint64, final bool) {
+	copy(nonce[:], header[5:5+chunkNoncePrefixSize])
+	for i := NonceSize - 2; i >= chunkNoncePrefixSize; i-- {
+		nonce[i] = byte(index)
+		index >>= 8
+	}
+	nonce[NonceSize-1] = 0
+	if final {
+		nonce[NonceSize-1] = 1
+	}
+}
+
+// A chunkJob is a single chunk to be sealed or opened.
+type chunkJob struct {
+	index uint64
+	final bool
+	in    []byte
+	out   []byte
+	err   error
+}
+
+// chunkPipeline reads chunks from a stream in batches,
+// processes each batch in parallel, and writes the results in order.
+type chunkPipeline struct {
+	inSize  int
+	workers int
+	jobs    []chunkJob
+}
+
+// newChunkPipeline returns a pipeline which reads inSize bytes for each chunk
+// and expects to write at most outSize.
+func newChunkPipeline(inSize, outSize, workers int) *chunkPipeline {
+	p := &chunkPipeline{inSize: inSize, workers: workers}
+	p.jobs = make([]chunkJob, workers)
+	for i := range p.jobs {
+		p.jobs[i].in = make([]byte, inSize)
+		p.jobs[i].out = make([]byte, 0, outSize)
+	}
+	return p
+}
+
+func (p *chunkPipeline) run(dst io.Writer, src io.Reader, do func(*chunkJob)) error {
+	r := bufio.NewReader(src)
+	var index uint64
+	for done := false; !done; {
+		// Read a batch
+		n := 0
+		for n < len(p.jobs) && !done {
+			if index >= maxChunks {
+				return ErrNonceExhausted
+			}
+			c := &p.jobs[n]
+			m, final, err := readChunk(r, c.in[:p.inSize])
+			if err != nil {
+				return err
+			}
+			c.index = index
+			c.final = final
+			c.in = c.in[:m]
+			c.err = nil
+			index++
+			n++
+			done = final
+		}
+
+		// Process it
+		var wg sync.WaitGroup
+		for w := 0; w < p.workers && w < n; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w; i < n; i += p.workers {
+					do(&p.jobs[i])
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		// Write it
+		for i := 0; i < n; i++ {
+			c := &p.jobs[i]
+			if c.err != nil {
+				return c.err
+			}
+			if _, err := dst.Write(c.out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readChunk reads up to len(buf) bytes
+// and reports whether they are the last bytes in the stream.
+func readChunk(r *bufio.Reader, buf []byte) (n int, final bool, err error) {
+	n, err = io.ReadFull(r, buf)
+	switch err {
+	case nil:
+		if _, err := r.Peek(1); err == io.EOF {
+			return n, true, nil
+		} else if err != nil {
+			return n, false, err
+		}
+		return n, false, nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		return n, true, nil
+	}
+	return n, false, err
+}
+
+// ChunkReader decrypts individual chunks of a chunked ciphertext
+// stored in an io.ReaderAt.
+// It is safe for concurrent use.
+type ChunkReader struct {
+	aead      *AEAD128
+	r         io.ReaderAt
+	header    []byte
+	chunkSize int
+	n         uint64 // number of chunks
+	lastSize  int    // size of the last chunk, including the tag
+}
+
+// NewChunkReader reads the header of the chunked ciphertext of the given size from r.
+func NewChunkReader(r io.ReaderAt, size int64, key []byte) (*ChunkReader, error) {
+	a, err := NewAEAD128(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, ChunkHeaderSize)
+	if size < ChunkHeaderSize {
+		return nil, errChunkHeader
+	}
+	if n, err := r.ReadAt(header, 0); n < len(header) {
+		return nil, err
+	}
+	chunkSize, err := parseChunkHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	body := size - ChunkHeaderSize
+	slot := int64(chunkSize + TagSize)
+	n := (body + slot - 1) / slot
+	last := body - (n-1)*slot
+	if n == 0 || last < TagSize || uint64(n) > maxChunks {
+		return nil, ErrOpen
+	}
+	return &ChunkReader{
+		aead:      a,
+		r:         r,
+		header:    header,
+		chunkSize: chunkSize,
+		n:         uint64(n),
+		lastSize:  int(last),
+	}, nil
+}
+
+// ChunkSize returns the number of plaintext bytes in every chunk except the last.
+func (c *ChunkReader) ChunkSize() int { return c.chunkSize }
+
+// NumChunks returns the number of chunks.
+func (c *ChunkReader) NumChunks() uint64 { return c.n }
+
+// ReadChunk decrypts chunk i and appends its plaintext to dst.
+// The plaintext of chunk i starts at offset i*ChunkSize() in the whole plaintext.
+func (c *ChunkReader) ReadChunk(dst []byte, i uint64) ([]byte, error) {
+	if i >= c.n {
+		return dst, fmt.Errorf("ascon: chunk %d out of range", i)
+	}
+	final := i == c.n-1
+	size := c.chunkSize + TagSize
+	if final {
+		size = c.lastSize
+	}
+	buf := make([]byte, size)
+	off := ChunkHeaderSize + int64(i)*int64(c.chunkSize+TagSize)
+	if n, err := c.r.ReadAt(buf, off); n < len(buf) {
+		return dst, err
+	}
+	var nonce [NonceSize]byte
+	chunkNonce(&nonce, c.header, i, final)
+	return c.aead.Open(dst, nonce[:], buf, c.header)
+}
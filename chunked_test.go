@@ -0,0 +1,131 @@
+package ascon
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func encryptChunked(t *testing.T, key, msg []byte, chunkSize, workers int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	opts := &ChunkOptions{
+		ChunkSize: chunkSize,
+		Workers:   workers,
+		Rand:      bytes.NewReader(mkPattern(chunkNoncePrefixSize, 0x20)),
+	}
+	if err := EncryptChunked(&buf, bytes.NewReader(msg), key, opts); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestChunked(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	const chunkSize = 16
+	for _, n := range []int{0, 1, 15, 16, 17, 32, 100} {
+		msg := mkPattern(n, 0x40)
+		c := encryptChunked(t, key, msg, chunkSize, 1)
+		chunks := (n + chunkSize - 1) / chunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		if want := ChunkHeaderSize + n + chunks*TagSize; len(c) != want {
+			t.Errorf("len=%d: ciphertext has length %d, want %d", n, len(c), want)
+		}
+		for _, workers := range []int{2, 3, 8} {
+			if c2 := encryptChunked(t, key, msg, chunkSize, workers); !bytes.Equal(c, c2) {
+				t.Errorf("len=%d: output with %d workers differs", n, workers)
+			}
+		}
+		for _, workers := range []int{1, 3} {
+			var out bytes.Buffer
+			err := DecryptChunked(&out, bytes.NewReader(c), key, &ChunkOptions{Workers: workers})
+			if err != nil {
+				t.Errorf("len=%d workers=%d: decryption failed: %v", n, workers, err)
+			} else if !bytes.Equal(out.Bytes(), msg) {
+				t.Errorf("len=%d workers=%d: decrypted %X, want %X", n, workers, out.Bytes(), msg)
+			}
+		}
+	}
+}
+
+func TestChunkedGolden(t *testing.T) {
+	c := encryptChunked(t, mkPattern(KeySize, 0x00), mkPattern(40, 0x40), 16, 1)
+	const want = "0100000010202122232425262736471F0C9C915B8A47CE499054CDB4EF99674C102C3FCC7ED87895DCB1C7F8886F6A2F08C8A2A1AF8249AF626AA7C4D4E23D6ABC15E8FFA3C4CA3386CDDA99572F8E09FE184DB3E053F80D53D0BFEEA131BA235FB71AF32F"
+	if got := fmt.Sprintf("%X", c); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestChunkedTamper(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	const chunkSize = 16
+	msg := mkPattern(48, 0x40)
+	c := encryptChunked(t, key, msg, chunkSize, 1)
+	slot := chunkSize + TagSize
+
+	decrypt := func(c []byte) error {
+		var out bytes.Buffer
+		return DecryptChunked(&out, bytes.NewReader(c), key, nil)
+	}
+	if err := decrypt(c); err != nil {
+		t.Fatal(err)
+	}
+
+	swapped := append([]byte(nil), c...)
+	copy(swapped[ChunkHeaderSize:], c[ChunkHeaderSize+slot:ChunkHeaderSize+2*slot])
+	copy(swapped[ChunkHeaderSize+slot:], c[ChunkHeaderSize:ChunkHeaderSize+slot])
+	tests := []struct {
+		name string
+		c    []byte
+	}{
+		{"header only", c[:ChunkHeaderSize]},
+		{"short header", c[:ChunkHeaderSize-1]},
+		{"truncated at chunk boundary", c[:ChunkHeaderSize+2*slot]},
+		{"truncated mid-chunk", c[:len(c)-1]},
+		{"extended", append(append([]byte(nil), c...), c[ChunkHeaderSize:ChunkHeaderSize+slot]...)},
+		{"swapped chunks", swapped},
+	}
+	for _, tt := range tests {
+		if err := decrypt(tt.c); err == nil {
+			t.Errorf("%s: decryption succeeded", tt.name)
+		}
+	}
+	for i := 0; i < ChunkHeaderSize; i++ {
+		c[i] ^= 1
+		if err := decrypt(c); err == nil {
+			t.Errorf("decryption succeeded with header byte %d modified", i)
+		}
+		c[i] ^= 1
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	const chunkSize = 16
+	for _, n := range []int{0, 16, 40} {
+		msg := mkPattern(n, 0x40)
+		c := encryptChunked(t, key, msg, chunkSize, 1)
+		r, err := NewChunkReader(bytes.NewReader(c), int64(len(c)), key)
+		if err != nil {
+			t.Errorf("len=%d: %v", n, err)
+			continue
+		}
+		var got []byte
+		for i := r.NumChunks(); i > 0; i-- {
+			// read backwards to check that chunks are independent
+			p, err := r.ReadChunk(nil, i-1)
+			if err != nil {
+				t.Errorf("len=%d: chunk %d: %v", n, i-1, err)
+			}
+			got = append(p, got...)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("len=%d: got %X, want %X", n, got, msg)
+		}
+		if _, err := r.ReadChunk(nil, r.NumChunks()); err == nil {
+			t.Errorf("len=%d: read past the last chunk", n)
+		}
+	}
+}
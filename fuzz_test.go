@@ -56,3 +56,32 @@ func FuzzAEAD(f *testing.F) {
 	})
 
 }
+
+// FuzzNoPanic checks that the error-returning API never panics on bad input.
+func FuzzNoPanic(f *testing.F) {
+	f.Add([]byte("my special key.."), []byte("my special nonce"), []byte("message"), "custom")
+	f.Add([]byte{}, []byte{}, []byte{}, "")
+	f.Fuzz(func(t *testing.T, key, nonce, data []byte, custom string) {
+		if a, err := NewAEAD128(key); err == nil {
+			a.TrySeal(nil, nonce, data, data)
+			a.TryOpen(nil, nonce, data, data)
+		}
+		if a, err := NewSIV128(key); err == nil {
+			a.TrySeal(nil, nonce, data, data)
+			a.TryOpen(nil, nonce, data, data)
+		}
+		if a, err := NewXAEAD128(key); err == nil {
+			a.TrySeal(nil, nonce, data, data)
+			a.TryOpen(nil, nonce, data, data)
+		}
+		if x, err := NewCxof128(custom); err == nil {
+			x.Write(data)
+			x.Read(make([]byte, 8))
+			x.Write(data)
+		}
+		var x Cxof128
+		x.Read(make([]byte, 8))
+		UnwrapKey(key, data)
+		new(Keyring).UnmarshalBinary(data)
+	})
+}
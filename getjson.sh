@@ -3,8 +3,8 @@ baseurl='https://github.com/usnistgov/ACVP-Server/raw/refs/tags/v1.1.0.40/gen-va
 
 set -euo pipefail
 
-if ! command -v jq >/dev/null || ! command -v curl >/dev/null; then
-    echo >&2 "error: $0 requires the 'jq' and 'curl' commands to be installed"
+if ! command -v jq >/dev/null || ! command -v curl >/dev/null || ! command -v unzip >/dev/null; then
+    echo >&2 "error: $0 requires the 'jq', 'curl' and 'unzip' commands to be installed"
     exit 1
 fi
 
@@ -12,7 +12,7 @@ fi
 # Hash
 mkdir -p json/hash
 curl -fsSL "$baseurl/Ascon-Hash256-SP800-232/prompt.json" |
-jq '[.testGroups[].tests[] | select(.len%8==0)]' >json/hash/simple.json
+jq '[.testGroups[].tests[]]' >json/hash/simple.json
 
 curl -fsSL "$baseurl/Ascon-Hash256-SP800-232/expectedResults.json" |
 jq --argjson ids "$(jq -c '[.[].tcId]' <json/hash/simple.json)" '[.testGroups[].tests[] | select(IN(.tcId; $ids[]))]' >json/hash/want.json
@@ -20,7 +20,7 @@ jq --argjson ids "$(jq -c '[.[].tcId]' <json/hash/simple.json)" '[.testGroups[].
 # XOF
 mkdir -p json/xof
 curl -fsSL "$baseurl/Ascon-XOF128-SP800-232/prompt.json" |
-jq '[.testGroups[].tests[] | select(.len%8==0 and .outLen%8==0)]' >json/xof/simple.json
+jq '[.testGroups[].tests[]]' >json/xof/simple.json
 
 curl -fsSL "$baseurl/Ascon-XOF128-SP800-232/expectedResults.json" |
 jq --argjson ids "$(jq -c '[.[].tcId]' <json/xof/simple.json)" '[.testGroups[].tests[] | select(IN(.tcId; $ids[]))]' >json/xof/want.json
@@ -28,7 +28,7 @@ jq --argjson ids "$(jq -c '[.[].tcId]' <json/xof/simple.json)" '[.testGroups[].t
 # CXOF
 mkdir -p json/cxof
 curl -fsSL "$baseurl/Ascon-CXOF128-SP800-232/prompt.json" |
-jq '[.testGroups[].tests[] | select(.len%8==0 and .csLen%8==0 and .outLen%8==0)]' >json/cxof/simple.json
+jq '[.testGroups[].tests[]]' >json/cxof/simple.json
 
 curl -fsSL "$baseurl/Ascon-CXOF128-SP800-232/expectedResults.json" |
 jq --argjson ids "$(jq -c '[.[].tcId]' <json/cxof/simple.json)" '[.testGroups[].tests[] | select(IN(.tcId; $ids[]))]' >json/cxof/want.json
@@ -37,7 +37,32 @@ jq --argjson ids "$(jq -c '[.[].tcId]' <json/cxof/simple.json)" '[.testGroups[].
 # AEAD
 mkdir -p json/aead
 curl -fsSL "$baseurl/Ascon-AEAD128-SP800-232/prompt.json" |
-jq '[.testGroups[].tests[] | select(.payloadLen%8 == 0 and .adLen%8 == 0 and .tagLen%8==0)]' >json/aead/simple.json
+jq '[.testGroups[].tests[]]' >json/aead/simple.json
 
 curl -fsSL "$baseurl/Ascon-AEAD128-SP800-232/expectedResults.json" |
 jq --argjson ids "$(jq -c '[.[].tcId]' <json/aead/simple.json)" '[.testGroups[].tests[] | select(IN(.tcId; $ids[]))]' >json/aead/want.json
+
+
+# NIST LWC KAT files, from the final round submission packages
+lwcurl='https://csrc.nist.gov/CSRC/media/Projects/lightweight-cryptography/documents/finalist-round/updated-submissions'
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+# lwckat ZIP DIR FILE extracts the KAT file FILE for the algorithm DIR
+# from the submission package ZIP into testdata/DIR/FILE
+lwckat() {
+    mkdir -p "testdata/$2"
+    unzip -p "$tmp/$1" "*/crypto_*/$2/$3" >"testdata/$2/$3"
+    test -s "testdata/$2/$3"
+}
+
+# ISAP-A-128a
+curl -fsSL "$lwcurl/isap.zip" -o "$tmp/isap.zip"
+lwckat isap.zip isapa128av20 LWC_AEAD_KAT_128_128.txt
+
+# Ascon-Hash, Ascon-Xof, Ascon-Hasha and Ascon-Xofa v1.2
+curl -fsSL "$lwcurl/ascon.zip" -o "$tmp/ascon.zip"
+lwckat ascon.zip asconhashv12 LWC_HASH_KAT_256.txt
+lwckat ascon.zip asconxofv12 LWC_HASH_KAT_256.txt
+lwckat ascon.zip asconhashav12 LWC_HASH_KAT_256.txt
+lwckat ascon.zip asconxofav12 LWC_HASH_KAT_256.txt
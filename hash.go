@@ -9,11 +9,22 @@ const HashSize = 256 / 8  // bytes
 const BlockSize = 64 / 8  // bytes
 const stateSize = 320 / 8 // bytes
 
+var (
+	// ErrCustomizationSize is returned when a customization string is longer than 256 bytes.
+	ErrCustomizationSize = errors.New("ascon: customization string too long")
+	// ErrWriteAfterRead is returned when writing to an XOF after reading from it.
+	ErrWriteAfterRead = errors.New("ascon: Write called after Read")
+	// ErrWriteAfterBits is returned by WriteBits, and Write panics with it,
+	// when writing to a hash after WriteBits was called with a partial byte.
+	ErrWriteAfterBits = errors.New("ascon: Write called after a partial byte")
+)
+
 // digest implements hash.Hash
 type digest struct {
-	s   state
-	buf [8]byte
-	len uint8 // number of bytes in buf
+	s    state
+	buf  [8]byte
+	len  uint8 // number of bytes in buf
+	bits uint8 // number of bits in buf[len] written by WriteBits
 
 	initialized bool
 	doneWriting bool
@@ -43,14 +54,32 @@ func (h *Hash256) Clone() *Hash256 {
 	return &new
 }
 
+// Write absorbs more data into the hash state. It never returns an error.
+// It panics with ErrWriteAfterBits if the message already ends with a partial byte.
 func (h *Hash256) Write(p []byte) (int, error) {
 	if h.digest.initialized == false {
 		h.Reset()
 	}
+	if h.digest.bits != 0 {
+		panic(ErrWriteAfterBits)
+	}
 	h.digest.write(p)
 	return len(p), nil
 }
 
+// WriteBits absorbs the first nbits bits of p,
+// numbered from the least significant bit of each byte as in NIST.SP.800-232.
+// If nbits is not a multiple of 8, the message ends with a partial byte
+// and any further writes fail: WriteBits returns ErrWriteAfterBits
+// and Write panics.
+// It returns ErrBitLength if nbits is negative or longer than p.
+func (h *Hash256) WriteBits(p []byte, nbits int) error {
+	if h.digest.initialized == false {
+		h.Reset()
+	}
+	return h.digest.writeBits(p, nbits)
+}
+
 // Xof128 is an implementation of the Ascon-XOF128 arbitrary-length hash algorithm.
 // It implements the golang.org/x/crypto/sha3.ShakeHash interface (minus Clone).
 type Xof128 struct{ digest }
@@ -70,18 +99,39 @@ func (x *Xof128) Clone() *Xof128 {
 func (x *Xof128) Reset() {
 	x.digest.initHash(3, 64, 12, 12, 0)
 	x.digest.len = 0
+	x.digest.bits = 0
 	x.digest.initialized = true
 	x.digest.doneWriting = false
 }
 
+// Write absorbs more data into the hash state.
+// It returns ErrWriteAfterRead if output has already been read,
+// and panics with ErrWriteAfterBits after a partial byte, like Hash256.Write.
 func (x *Xof128) Write(p []byte) (int, error) {
 	if x.digest.initialized == false {
 		x.Reset()
 	}
+	if x.digest.doneWriting {
+		return 0, ErrWriteAfterRead
+	}
+	if x.digest.bits != 0 {
+		panic(ErrWriteAfterBits)
+	}
 	x.digest.write(p)
 	return len(p), nil
 }
 
+// WriteBits absorbs the first nbits bits of p, like Hash256.WriteBits.
+func (x *Xof128) WriteBits(p []byte, nbits int) error {
+	if x.digest.initialized == false {
+		x.Reset()
+	}
+	if x.digest.doneWriting {
+		return ErrWriteAfterRead
+	}
+	return x.digest.writeBits(p, nbits)
+}
+
 func (x *Xof128) Read(p []byte) (int, error) {
 	if x.digest.initialized == false {
 		x.Reset()
@@ -90,31 +140,104 @@ func (x *Xof128) Read(p []byte) (int, error) {
 	return len(p), nil
 }
 
+// ReadBits reads nbits bits of output into the first (nbits+7)/8 bytes of p.
+// Any unused bits in the last byte are zeroed and discarded,
+// so the next read starts at the following byte of output.
+// It returns ErrBitLength if nbits is negative or longer than p.
+func (x *Xof128) ReadBits(p []byte, nbits int) error {
+	if !bitsOK(p, nbits) {
+		return ErrBitLength
+	}
+	if x.digest.initialized == false {
+		x.Reset()
+	}
+	x.digest.readBits(p, nbits)
+	return nil
+}
+
 // Cxof128 is an implementation of the Ascon-CXOF128 customized arbitrary-length hash algorithm.
 // It implements the golang.org/x/crypto/sha3.ShakeHash interface (minus Clone).
+// The zero value is ready to use, with an empty customization string.
 type Cxof128 struct {
 	digest       digest
 	initialState *state
 }
 
+// NewCxof128 returns a Cxof128 with the given customization string.
+// It returns ErrCustomizationSize if the string is longer than 256 bytes.
 func NewCxof128(customizationString string) (*Cxof128, error) {
 	// "The length of the customization string shall be at most 2048 bits (i.e., 256 bytes)."
 	if len(customizationString) > 256 {
-		return nil, errors.New("ascon: customization string too long")
+		return nil, ErrCustomizationSize
+	}
+	x := new(Cxof128)
+	x.init(customizationString)
+	return x, nil
+}
+
+// NewCxof128Bits returns a Cxof128 whose customization string
+// is the first nbits bits of custom, numbered as in Hash256.WriteBits.
+// It returns ErrCustomizationSize if nbits is more than 2048,
+// and ErrBitLength if it is negative or longer than custom.
+func NewCxof128Bits(custom []byte, nbits int) (*Cxof128, error) {
+	if nbits > 256*8 {
+		return nil, ErrCustomizationSize
+	}
+	if !bitsOK(custom, nbits) {
+		return nil, ErrBitLength
 	}
 	x := new(Cxof128)
-	x.digest.initHash(4, 64, 12, 12, 0)
-	// absorb Z_0, the length of the customization string (in bits) encoded as a uint64
-	x.digest.s[0] ^= uint64(len(customizationString)) * 8
-	x.digest.permute()
-	// absorb the customization string
-	x.digest.write([]byte(customizationString))
+	x.initBits(custom, nbits)
+	return x, nil
+}
+
+// init initializes x with the given customization string,
+// which must be at most 256 bytes long.
+func (x *Cxof128) init(customizationString string) {
+	x.digest.initCxof(len(customizationString) * 8)
+	x.digest.writeString(customizationString)
+	x.finishInit()
+}
+
+// initBits initializes x with a customization string of nbits bits,
+// which must be at most 2048.
+func (x *Cxof128) initBits(custom []byte, nbits int) {
+	x.digest.initCxof(nbits)
+	x.digest.writeBits(custom, nbits)
+	x.finishInit()
+}
+
+// finishInit finishes absorbing the customization string
+// and saves the initial state.
+func (x *Cxof128) finishInit() {
 	x.digest.finish() // flush buffer and pad
-	// save the initial state
-	s := x.digest.s // make a copy
+	s := x.digest.s   // make a copy
 	x.initialState = &s
 	x.digest.initialized = true
-	return x, nil
+}
+
+// initCxof initializes d for Ascon-CXOF128 and absorbs Z_0,
+// the length of the customization string in bits encoded as a uint64.
+func (d *digest) initCxof(nbits int) {
+	d.initHash(4, 64, 12, 12, 0)
+	d.len = 0
+	d.bits = 0
+	d.doneWriting = false
+	d.s[0] ^= uint64(nbits)
+	d.permute()
+}
+
+// newKeyedCxof returns a Cxof128 with the given customization string
+// which has already absorbed key, prefixed by its length in bits.
+// It is the keyed primitive underlying the package's MAC-based constructions.
+func newKeyedCxof(customizationString string, key []byte) Cxof128 {
+	x, err := NewCxof128(customizationString)
+	if err != nil {
+		panic(err)
+	}
+	x.digest.writeLength(len(key))
+	x.digest.write(key)
+	return *x
 }
 
 // Clone returns a new copy of x.
@@ -125,30 +248,67 @@ func (x *Cxof128) Clone() *Cxof128 {
 
 func (x *Cxof128) Reset() {
 	if x.digest.initialized == false {
-		panic("ascon: reset of uninitialized CXOF")
+		x.init("")
+		return
 	}
 	x.digest.s = *x.initialState
 	x.digest.len = 0
+	x.digest.bits = 0
 	x.digest.initialized = true
 	x.digest.doneWriting = false
 }
 
+// Write absorbs more data into the hash state.
+// It returns ErrWriteAfterRead if output has already been read,
+// and panics with ErrWriteAfterBits after a partial byte, like Hash256.Write.
 func (x *Cxof128) Write(p []byte) (int, error) {
 	if x.digest.initialized == false {
-		panic("ascon: write to uninitialized CXOF")
+		x.init("")
+	}
+	if x.digest.doneWriting {
+		return 0, ErrWriteAfterRead
+	}
+	if x.digest.bits != 0 {
+		panic(ErrWriteAfterBits)
 	}
 	x.digest.write(p)
 	return len(p), nil
 }
 
+// WriteBits absorbs the first nbits bits of p, like Hash256.WriteBits.
+func (x *Cxof128) WriteBits(p []byte, nbits int) error {
+	if x.digest.initialized == false {
+		x.init("")
+	}
+	if x.digest.doneWriting {
+		return ErrWriteAfterRead
+	}
+	return x.digest.writeBits(p, nbits)
+}
+
 func (x *Cxof128) Read(p []byte) (int, error) {
 	if x.digest.initialized == false {
-		panic("ascon: read from uninitialized CXOF")
+		x.init("")
 	}
 	x.digest.read(p)
 	return len(p), nil
 }
 
+// ReadBits reads nbits bits of output into the first (nbits+7)/8 bytes of p.
+// Any unused bits in the last byte are zeroed and discarded,
+// so the next read starts at the following byte of output.
+// It returns ErrBitLength if nbits is negative or longer than p.
+func (x *Cxof128) ReadBits(p []byte, nbits int) error {
+	if !bitsOK(p, nbits) {
+		return ErrBitLength
+	}
+	if x.digest.initialized == false {
+		x.init("")
+	}
+	x.digest.readBits(p, nbits)
+	return nil
+}
+
 // The data rate of the sponge, in bytes.
 // Writes which are a multiple of BlockSize will be more performant.
 func (d *digest) BlockSize() int { return BlockSize }
@@ -163,6 +323,7 @@ func (d *digest) reset() {
 	d.s[4] = 0x1a5c464906c5976d
 	d.buf = [8]byte{}
 	d.len = 0
+	d.bits = 0
 	d.initialized = true
 	d.doneWriting = false
 }
@@ -211,43 +372,92 @@ func (d *digest) write(b []byte) {
 	}
 }
 
+// writeBits absorbs the first nbits bits of b.
+// A partial final byte is kept in the buffer, and d.bits records its length.
+func (d *digest) writeBits(b []byte, nbits int) error {
+	if !bitsOK(b, nbits) {
+		return ErrBitLength
+	}
+	if d.bits != 0 {
+		return ErrWriteAfterBits
+	}
+	n := nbits / 8
+	d.write(b[:n])
+	if r := uint8(nbits % 8); r != 0 {
+		// d.write leaves at least one byte free in the buffer
+		d.buf[d.len] = b[n] & (1<<r - 1)
+		d.bits = r
+	}
+	return nil
+}
+
+// writeString absorbs s a block at a time,
+// so that it doesn't need to be converted to a []byte.
+func (d *digest) writeString(s string) {
+	var buf [BlockSize]byte
+	for len(s) > 0 {
+		n := copy(buf[:], s)
+		d.write(buf[:n])
+		s = s[n:]
+	}
+}
+
+// writeLength absorbs n*8, the bit length of a byte string, encoded as a little-endian uint64.
+func (d *digest) writeLength(n int) {
+	var b [8]byte
+	le64enc(b[:], uint64(n)*8)
+	d.write(b[:])
+}
+
 func (d *digest) finish() {
 	if int(d.len) >= len(d.buf) {
 		panic("ascon: internal error")
 	}
 
-	// Pad with a 1 followed by zeroes
+	// Pad with a 1 followed by zeroes,
+	// after any bits of a partial byte
 	const bs = BlockSize
 	for i := d.len + 1; i < bs; i++ {
 		d.buf[i] = 0
 	}
-	d.buf[d.len] = 1
+	d.buf[d.len] = d.buf[d.len]&(1<<d.bits-1) | 1<<d.bits
 
 	// absorb the last block
 	d.s[0] ^= le64dec(d.buf[0:])
 	d.permute()
 	d.len = 0
+	d.bits = 0
 }
 
-func (d *digest) clone() *digest {
-	d0 := *d
-	return &d0
+func (d *digest) sum(b []byte) []byte {
+	var d0 digest
+	return d.sumTo(&d0, b)
 }
 
-func (d *digest) sum(b []byte) []byte {
-	d = d.clone()
-	d.finish()
+// sumTo implements sum, using d0 as scratch space.
+// d0 is wiped before returning.
+func (d *digest) sumTo(d0 *digest, b []byte) []byte {
+	*d0 = *d
+	d0.finish()
 
 	// Squeeze
 	for i := 0; i < HashSize/8; i++ {
 		if i != 0 {
-			d.permute()
+			d0.permute()
 		}
-		b = le64append(b, d.s[0])
+		b = le64append(b, d0.s[0])
 	}
+	d0.wipe()
 	return b
 }
 
+// wipe zeroes the digest, like state.wipe.
+//
+//go:noinline
+func (d *digest) wipe() {
+	*d = digest{}
+}
+
 // Reads len(p) bytes of hash output. The error is always nil.
 func (d *digest) read(p []byte) {
 	if !d.doneWriting {
@@ -303,6 +513,16 @@ func (d *digest) read(p []byte) {
 	}
 }
 
+// readBits reads nbits bits of hash output into p,
+// zeroing the unused bits of the last byte.
+func (d *digest) readBits(p []byte, nbits int) {
+	p = p[:(nbits+7)/8]
+	d.read(p)
+	if r := nbits % 8; r != 0 {
+		p[len(p)-1] &= 1<<uint(r) - 1
+	}
+}
+
 // Reads len(p) bytes of hash output in one shot.
 // Must be a multiple of BlockSize.
 // Used for testing Read.
@@ -0,0 +1,100 @@
+package ascon
+
+// HiddenNonceAEAD128 is an Ascon-AEAD128 which encrypts the nonce it sends,
+// so that observers can't see counters or other structure in the nonces.
+//
+// Seal takes a nonce from a NonceSource and seals the message with AEAD128
+// under an encryption subkey derived from the key.
+// It then masks the nonce with the output of Ascon-CXOF128,
+// keyed with a second subkey, over the tag.
+// The output is
+//
+//	maskedNonce [NonceSize]byte
+//	ciphertext  [len(plaintext)]byte
+//	tag         [TagSize]byte
+//
+// Open recomputes the mask from the tag, unmasks the nonce and opens the message.
+// Since the tag is pseudorandom, so is the mask;
+// this is the HN1 construction of Bellare, Ng and Tackmann,
+// "Nonces Are Noticed: AEAD Revisited" (CRYPTO 2019).
+//
+// Methods are safe for concurrent use if the NonceSource is.
+type HiddenNonceAEAD128 struct {
+	enc    AEAD128 // keyed with the encryption subkey
+	mask   Cxof128 // keyed with the key; never finalized
+	nonces NonceSource
+}
+
+const (
+	hiddenNonceEncCustomization  = "Ascon-HN128 ENC"
+	hiddenNonceMaskCustomization = "Ascon-HN128 MASK"
+)
+
+// NewHiddenNonceAEAD128 returns a HiddenNonceAEAD128 with the given key
+// which takes nonces from nonces. If nonces is nil, random nonces are used.
+func NewHiddenNonceAEAD128(key []byte, nonces NonceSource) (*HiddenNonceAEAD128, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	if nonces == nil {
+		nonces = RandomNonces{}
+	}
+	a := &HiddenNonceAEAD128{nonces: nonces}
+	a.mask = newKeyedCxof(hiddenNonceMaskCustomization, key)
+	var enc [KeySize]byte
+	kdf := newKeyedCxof(hiddenNonceEncCustomization, key)
+	kdf.digest.read(enc[:])
+	kdf.digest.wipe()
+	a.enc.SetKey(enc[:])
+	for i := range enc {
+		enc[i] = 0
+	}
+	return a, nil
+}
+
+// Overhead returns the difference between the lengths of a ciphertext and its plaintext.
+func (*HiddenNonceAEAD128) Overhead() int { return NonceSize + TagSize }
+
+// Seal encrypts and authenticates a plaintext with a fresh nonce
+// and appends the masked nonce and ciphertext to dst, returning the appended slice.
+// It only fails if the nonce source does.
+func (a *HiddenNonceAEAD128) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	var nonce [NonceSize]byte
+	if err := a.nonces.Nonce(nonce[:]); err != nil {
+		return dst, err
+	}
+	dstLen := len(dst)
+	dst = append(dst, make([]byte, NonceSize)...)
+	dst = a.enc.Seal(dst, nonce[:], plaintext, additionalData)
+	tag := dst[len(dst)-TagSize:]
+
+	var mask [NonceSize]byte
+	a.nonceMask(&mask, tag)
+	for i := range mask {
+		dst[dstLen+i] = nonce[i] ^ mask[i]
+	}
+	return dst, nil
+}
+
+// Open decrypts and authenticates a ciphertext produced by Seal
+// and appends the plaintext to dst, returning the appended slice.
+func (a *HiddenNonceAEAD128) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < NonceSize+TagSize {
+		return dst, ErrOpen
+	}
+	tag := ciphertext[len(ciphertext)-TagSize:]
+	var nonce [NonceSize]byte
+	a.nonceMask(&nonce, tag)
+	for i := range nonce {
+		nonce[i] ^= ciphertext[i]
+	}
+	return a.enc.Open(dst, nonce[:], ciphertext[NonceSize:], additionalData)
+}
+
+// nonceMask computes the mask for the nonce from the tag.
+func (a *HiddenNonceAEAD128) nonceMask(mask *[NonceSize]byte, tag []byte) {
+	x := a.mask // copy
+	x.digest.write(tag)
+	x.digest.read(mask[:])
+	x.digest.wipe()
+}
@@ -0,0 +1,78 @@
+package ascon
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestHiddenNonce(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	text := mkPattern(18, 0x20)
+	ad := mkPattern(6, 0x30)
+	nonces, _ := NewCounterNonces(mkPattern(8, 0x10))
+	a, err := NewHiddenNonceAEAD128(key, nonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"8C0CCFA0F1D716F32231131E1806708198B1DC009C99D2709E19B3FEC51E20C54D6DB26F129CC6A33538B7D103DE9FF5031E",
+		"6D869326A8DA024BE723A0258C9DB9301EF3947B941E227BFDE47773578734827DB64138F86EC338B550B37F8FA209981112",
+	}
+	var prev []byte
+	for i, w := range want {
+		c, err := a.Seal(nil, text, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%X", c); got != w {
+			t.Errorf("message %d: got %s, want %s", i, got, w)
+		}
+		if len(c) != len(text)+a.Overhead() {
+			t.Errorf("message %d: ciphertext has length %d, want %d", i, len(c), len(text)+a.Overhead())
+		}
+		// consecutive counters should not be visible
+		if prev != nil && bytes.Equal(c[:8], prev[:8]) {
+			t.Errorf("message %d: masked nonce shares a prefix with the previous one", i)
+		}
+		prev = c
+
+		// the real nonce is recovered from the tag
+		var nonce [NonceSize]byte
+		a.nonceMask(&nonce, c[len(c)-TagSize:])
+		for j := range nonce {
+			nonce[j] ^= c[j]
+		}
+		if want := fmt.Sprintf("%X%016X", mkPattern(8, 0x10), i); fmt.Sprintf("%X", nonce) != want {
+			t.Errorf("message %d: unmasked nonce is %X, want %s", i, nonce, want)
+		}
+
+		p, err := a.Open(nil, c, ad)
+		if err != nil {
+			t.Errorf("message %d: Open failed: %v", i, err)
+		} else if !bytes.Equal(p, text) {
+			t.Errorf("message %d: Open returned %X, want %X", i, p, text)
+		}
+		for j := range c {
+			c[j] ^= 1
+			if _, err := a.Open(nil, c, ad); err == nil {
+				t.Errorf("message %d: Open succeeded with byte %d modified", i, j)
+			}
+			c[j] ^= 1
+		}
+	}
+
+	if _, err := a.Open(nil, make([]byte, NonceSize+TagSize-1), nil); err == nil {
+		t.Error("Open succeeded with a short ciphertext")
+	}
+
+	// random nonces by default
+	r, _ := NewHiddenNonceAEAD128(key, nil)
+	c, err := r.Seal([]byte("prefix"), text, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := r.Open(nil, c[len("prefix"):], ad); err != nil || !bytes.Equal(p, text) {
+		t.Errorf("Open with random nonce = %X, %v", p, err)
+	}
+}
@@ -0,0 +1,183 @@
+package ascon
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// ISAP-A-128a, from ISAP v2.0 (a finalist in the NIST lightweight cryptography competition).
+// https://isap.iaik.tugraz.at/
+//
+// ISAP is designed to resist side-channel attacks without masking.
+// Before the key touches any data, it is re-keyed with the nonce (for encryption)
+// or with a hash of the message (for authentication),
+// absorbing one bit per permutation call, so that an attacker
+// gets only a few traces for each derived key.
+//
+// Unlike the SP 800-232 algorithms in this package,
+// ISAP loads bytes into the state in big-endian order,
+// like the original Ascon v1.2.
+
+const (
+	// rH = 64, rB = 1
+	isapRoundsH = 12 // sH
+	isapRoundsB = 1  // sB
+	isapRoundsE = 6  // sE
+	isapRoundsK = 12 // sK
+
+	// IV = flag || k || rH || rB || sH || sB || sE || sK
+	isapIVA  = 0x01_80_40_01_0C_01_06_0C
+	isapIVKA = 0x02_80_40_01_0C_01_06_0C
+	isapIVKE = 0x03_80_40_01_0C_01_06_0C
+)
+
+// ISAPA128A provides an implementation of ISAP-A-128a.
+// It implements the crypto/cipher.AEAD interface.
+//
+// ISAP-A-128a uses the same key, nonce and tag sizes as AEAD128,
+// but is not compatible with it.
+type ISAPA128A struct {
+	// the key, decoded into big-endian lanes
+	k0, k1 uint64
+}
+
+// NewISAPA128A returns an ISAPA128A with the given key.
+// It returns ErrKeySize if the key is not KeySize bytes long.
+func NewISAPA128A(key []byte) (*ISAPA128A, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	return &ISAPA128A{k0: be64dec(key[0:]), k1: be64dec(key[8:])}, nil
+}
+
+func (*ISAPA128A) NonceSize() int { return NonceSize }
+func (*ISAPA128A) Overhead() int  { return TagSize }
+
+// Seal encrypts and authenticates a plaintext
+// and appends ciphertext to dst, returning the appended slice.
+func (a *ISAPA128A) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize {
+		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
+	}
+	n0, n1 := be64dec(nonce[0:]), be64dec(nonce[8:])
+
+	dstLen := len(dst)
+	dst = extend(dst, len(plaintext)+TagSize)
+	c := dst[dstLen : dstLen+len(plaintext)]
+
+	var s state
+	a.encrypt(&s, n0, n1, plaintext, c)
+	t0, t1 := a.mac(&s, n0, n1, additionalData, c)
+	s.wipe()
+
+	be64enc(dst[dstLen+len(plaintext):], t0)
+	be64enc(dst[dstLen+len(plaintext)+8:], t1)
+	return dst
+}
+
+// Open authenticates and decrypts a ciphertext
+// and appends the plaintext to dst, returning the appended slice.
+// The ciphertext is only decrypted if the tag is valid.
+func (a *ISAPA128A) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
+	}
+	if len(ciphertext) < TagSize {
+		return dst, ErrOpen
+	}
+	n0, n1 := be64dec(nonce[0:]), be64dec(nonce[8:])
+	plaintextSize := len(ciphertext) - TagSize
+	expectedTag := ciphertext[plaintextSize:]
+	ciphertext = ciphertext[:plaintextSize]
+
+	var s state
+	t0, t1 := a.mac(&s, n0, n1, additionalData, ciphertext)
+	var tag [TagSize]byte
+	be64enc(tag[0:], t0)
+	be64enc(tag[8:], t1)
+	if subtle.ConstantTimeCompare(tag[:], expectedTag) != 1 {
+		s.wipe()
+		return dst, ErrOpen
+	}
+
+	dstLen := len(dst)
+	dst = extend(dst, plaintextSize)
+	a.encrypt(&s, n0, n1, ciphertext, dst[dstLen:])
+	s.wipe()
+	return dst, nil
+}
+
+// rekey implements ISAP_RK, absorbing the 128-bit value y0 || y1
+// one bit at a time into a state initialized with the key and iv.
+// The derived key is the first 128 or 192 bits of s.
+func (a *ISAPA128A) rekey(s *state, iv, y0, y1 uint64) {
+	*s = state{a.k0, a.k1, iv, 0, 0}
+	s.rounds(isapRoundsK)
+	for i := 0; i < 128; i++ {
+		y := y0
+		if i >= 64 {
+			y = y1
+		}
+		s[0] ^= (y << uint(i%64)) & (1 << 63)
+		if i < 127 {
+			s.rounds(isapRoundsB)
+		}
+	}
+	s.rounds(isapRoundsK)
+}
+
+// encrypt implements ISAP_ENC, XORing in with the keystream into out.
+// It is its own inverse.
+func (a *ISAPA128A) encrypt(s *state, n0, n1 uint64, in, out []byte) {
+	// The session key is the first 192 bits of the re-keyed state
+	a.rekey(s, isapIVKE, n0, n1)
+	s[3] = n0
+	s[4] = n1
+	for len(in) >= 8 {
+		s.rounds(isapRoundsE)
+		be64enc(out, be64dec(in)^s[0])
+		in = in[8:]
+		out = out[8:]
+	}
+	if len(in) > 0 {
+		s.rounds(isapRoundsE)
+		for i := range in {
+			out[i] = in[i] ^ byte(s[0]>>(56-8*uint(i)))
+		}
+	}
+}
+
+// mac implements ISAP_MAC and returns the tag.
+func (a *ISAPA128A) mac(s *state, n0, n1 uint64, additionalData, ciphertext []byte) (t0, t1 uint64) {
+	*s = state{n0, n1, isapIVA, 0, 0}
+	s.rounds(isapRoundsH)
+	s.isapAbsorb(additionalData)
+	// domain separation
+	s[4] ^= 1
+	s.isapAbsorb(ciphertext)
+
+	// Re-key with the first 128 bits of the state
+	// and replace them with the derived key
+	var k state
+	a.rekey(&k, isapIVKA, s[0], s[1])
+	s[0] = k[0]
+	s[1] = k[1]
+	k.wipe()
+	s.rounds(isapRoundsH)
+	return s[0], s[1]
+}
+
+// isapAbsorb absorbs b with a 64-bit rate,
+// padded with a 1 bit and zeroes to a multiple of the rate.
+func (s *state) isapAbsorb(b []byte) {
+	for len(b) >= 8 {
+		s[0] ^= be64dec(b)
+		s.rounds(isapRoundsH)
+		b = b[8:]
+	}
+	var buf [8]byte
+	n := copy(buf[:], b)
+	buf[n] = 0x80 // Pad
+	s[0] ^= be64dec(buf[:])
+	s.rounds(isapRoundsH)
+}
@@ -0,0 +1,126 @@
+package ascon
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var _ cipher.AEAD = (*ISAPA128A)(nil)
+
+// Regression values computed by this package,
+// numbered like the entries of isapKatFile,
+// with Key = Nonce = 000102...0F and PT and AD counting up from 00.
+// TestISAPKat is the test against the official vectors.
+var isapTests = []struct {
+	count  int
+	ptLen  int
+	adLen  int
+	hexOut string
+}{
+	{1, 0, 0, "7B94EF35AE55AB272C9C44D6C1CF0102"},
+	{2, 0, 1, "40FEAD6FDF1C2D6D6EAE40DEDDFF9F55"},
+	{34, 1, 0, "2CFACF138C6FDBBCC8763A7205FD66316D"},
+	{571, 17, 9, "2CDE28DBBBD9131EBC568D77725B25937C787881E676CA908010D93F0A52DE5F1E"},
+}
+
+func TestISAP(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(NonceSize, 0x00)
+	a, err := NewISAPA128A(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range isapTests {
+		pt := mkPattern(tt.ptLen, 0x00)
+		ad := mkPattern(tt.adLen, 0x00)
+		c := a.Seal(nil, nonce, pt, ad)
+		if got := fmt.Sprintf("%X", c); got != tt.hexOut {
+			t.Errorf("Count = %d: got %s, want %s", tt.count, got, tt.hexOut)
+		}
+		p, err := a.Open(nil, nonce, c, ad)
+		if err != nil {
+			t.Errorf("Count = %d: Open failed: %v", tt.count, err)
+		} else if !bytes.Equal(p, pt) {
+			t.Errorf("Count = %d: Open returned %X, want %X", tt.count, p, pt)
+		}
+		for i := range c {
+			c[i] ^= 0x01
+			if _, err := a.Open(nil, nonce, c, ad); err == nil {
+				t.Errorf("Count = %d: Open succeeded with byte %d modified", tt.count, i)
+			}
+			c[i] ^= 0x01
+		}
+	}
+	if _, err := NewISAPA128A(key[:8]); err != ErrKeySize {
+		t.Errorf("NewISAPA128A with a short key: got %v, want ErrKeySize", err)
+	}
+}
+
+func TestISAPInPlace(t *testing.T) {
+	a, _ := NewISAPA128A(mkPattern(KeySize, 0x00))
+	testAEADInPlace(t, a)
+}
+
+// isapKatFile is the KAT file from the ISAP submission package
+// (Implementations/crypto_aead/isapa128av20/LWC_AEAD_KAT_128_128.txt),
+// downloaded by getjson.sh.
+var isapKatFile = filepath.Join("testdata", "isapa128av20", "LWC_AEAD_KAT_128_128.txt")
+
+func TestISAPKat(t *testing.T) {
+	n := 0
+	readKat(t, isapKatFile, func(kv map[string]string) {
+		n++
+		key, nonce := unhex(kv["Key"]), unhex(kv["Nonce"])
+		pt, ad, ct := unhex(kv["PT"]), unhex(kv["AD"]), unhex(kv["CT"])
+		a, err := NewISAPA128A(key)
+		if err != nil {
+			t.Errorf("Count = %s: %v", kv["Count"], err)
+			return
+		}
+		if got := a.Seal(nil, nonce, pt, ad); !bytes.Equal(got, ct) {
+			t.Errorf("Count = %s: got %X, want %X", kv["Count"], got, ct)
+		}
+		if got, err := a.Open(nil, nonce, ct, ad); err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("Count = %s: Open = %X, %v", kv["Count"], got, err)
+		}
+	})
+	if n != 33*33 {
+		t.Errorf("got %d test vectors from %s, want %d", n, isapKatFile, 33*33)
+	}
+}
+
+func TestGenKatISAP(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	f, err := os.Create("isap_a_128a_kat.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	num := 0
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(NonceSize, 0x00)
+	a, _ := NewISAPA128A(key)
+	for i := 0; i <= 32; i++ {
+		for j := 0; j <= 32; j++ {
+			num += 1
+			msg := mkPattern(i, 0x00)
+			ad := mkPattern(j, 0x00)
+			fmt.Fprintf(w, "Count = %d\n", num)
+			fmt.Fprintf(w, "Key = %X\n", key)
+			fmt.Fprintf(w, "Nonce = %X\n", nonce)
+			fmt.Fprintf(w, "PT = %X\n", msg)
+			fmt.Fprintf(w, "AD = %X\n", ad)
+			fmt.Fprintf(w, "CT = %X\n", a.Seal(nil, nonce, msg, ad))
+			fmt.Fprintln(w)
+		}
+	}
+}
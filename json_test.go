@@ -94,7 +94,10 @@ func TestHashJson(t *testing.T) {
 		}
 
 		h := NewHash256()
-		h.Write(msg)
+		if err := h.WriteBits(msg, tc.MsgLen); err != nil {
+			t.Errorf("tcId=%d: unexpected error from WriteBits: %v", tc.TcId, err)
+			continue
+		}
 		checkBytes(t, tc.TcId, "sum", h.Sum(nil), want.Md)
 		// check that Sum is idempotent
 		checkBytes(t, tc.TcId, "second sum", h.Sum(nil), want.Md)
@@ -110,25 +113,13 @@ func TestXofJson(t *testing.T) {
 			t.Error("msg", err)
 			continue
 		}
-		n, err := x.Write(message)
-		if n != len(message) || err != nil {
-			if n != len(message) {
-				t.Error("short write")
-			}
-			if err != nil {
-				t.Errorf("unexpected error from Write: %v", err)
-			}
+		if err := x.WriteBits(message, tc.MsgLen); err != nil {
+			t.Errorf("tcId=%d: unexpected error from WriteBits: %v", tc.TcId, err)
 			continue
 		}
-		output := make([]byte, tc.OutLen/8)
-		n, err = x.Read(output)
-		if n != len(output) || err != nil {
-			if n != len(message) {
-				t.Error("short read")
-			}
-			if err != nil {
-				t.Errorf("unexpected error from Read: %v", err)
-			}
+		output := make([]byte, (tc.OutLen+7)/8)
+		if err := x.ReadBits(output, tc.OutLen); err != nil {
+			t.Errorf("tcId=%d: unexpected error from ReadBits: %v", tc.TcId, err)
 		}
 		want := &expectedResults[tcIndex]
 		checkBytes(t, tc.TcId, "output", output, want.Md)
@@ -150,30 +141,18 @@ func TestXCOFJson(t *testing.T) {
 			continue
 		}
 
-		x, err := NewCxof128(string(custom))
+		x, err := NewCxof128Bits(custom, tc.CsLen)
 		if err != nil {
 			t.Error(err)
 			continue
 		}
-		n, err := x.Write(message)
-		if n != len(message) || err != nil {
-			if n != len(message) {
-				t.Error("short write")
-			}
-			if err != nil {
-				t.Errorf("unexpected error from Write: %v", err)
-			}
+		if err := x.WriteBits(message, tc.MsgLen); err != nil {
+			t.Errorf("tcId=%d: unexpected error from WriteBits: %v", tc.TcId, err)
 			continue
 		}
-		output := make([]byte, tc.OutLen/8)
-		n, err = x.Read(output)
-		if n != len(output) || err != nil {
-			if n != len(message) {
-				t.Error("short read")
-			}
-			if err != nil {
-				t.Errorf("unexpected error from Read: %v", err)
-			}
+		output := make([]byte, (tc.OutLen+7)/8)
+		if err := x.ReadBits(output, tc.OutLen); err != nil {
+			t.Errorf("tcId=%d: unexpected error from ReadBits: %v", tc.TcId, err)
 		}
 		want := &expectedResults[tcIndex]
 		checkBytes(t, tc.TcId, "output", output, want.Md)
@@ -182,13 +161,7 @@ func TestXCOFJson(t *testing.T) {
 
 func TestAEADJson(t *testing.T) {
 	prompts, expectedResults := loadJson(t, "json/aead")
-	_ = prompts
-	_ = expectedResults
 	for tcIndex, tc := range prompts {
-		if tc.TagLen != 8*TagSize {
-			//t.Errorf("skipping test %d with unsupported tag size", tc.TcId)
-			continue
-		}
 		key, err := hex.DecodeString(tc.Key)
 		if err != nil {
 			t.Error("key", err)
@@ -229,7 +202,7 @@ func TestAEADJson(t *testing.T) {
 				t.Error("pt", err)
 				continue
 			}
-			ciphertext := a.Seal(nil, nonce, text, ad)
+			ciphertext := a.SealBits(nil, nonce, text, tc.PayloadLen, ad, tc.AdLen, tc.TagLen)
 			checkBytes(t, tc.TcId, "ct", ciphertext, want.Ct+want.Tag)
 		}
 		if tc.Ct != "" {
@@ -238,7 +211,7 @@ func TestAEADJson(t *testing.T) {
 				t.Error("ct", err)
 				continue
 			}
-			text, err := a.Open(nil, nonce, ciphertext, ad)
+			text, err := a.OpenBits(nil, nonce, ciphertext, tc.PayloadLen, ad, tc.AdLen, tc.TagLen)
 			if err != nil {
 				if want.TestPassed == nil || *want.TestPassed == true {
 					t.Errorf("tcId=%d: unexpected error: %v", tc.TcId, err)
@@ -0,0 +1,323 @@
+package ascon
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// KeyID identifies a key in a Keyring.
+type KeyID uint32
+
+// KeyState is the lifecycle state of a key in a Keyring.
+type KeyState uint8
+
+const (
+	// An enabled key can be used to open messages,
+	// and to seal them if it is the primary key.
+	KeyEnabled KeyState = 1 + iota
+	// A disabled key can't be used, but can be enabled again.
+	KeyDisabled
+	// A destroyed key has had its key material erased.
+	// Only its ID is remembered.
+	KeyDestroyed
+)
+
+func (s KeyState) String() string {
+	switch s {
+	case KeyEnabled:
+		return "enabled"
+	case KeyDisabled:
+		return "disabled"
+	case KeyDestroyed:
+		return "destroyed"
+	}
+	return fmt.Sprintf("KeyState(%d)", uint8(s))
+}
+
+// KeyringHeaderSize is the size of the header which Keyring.Seal
+// prepends to each ciphertext: a version byte followed by the big-endian key ID.
+const KeyringHeaderSize = 1 + 4
+
+const keyringVersion = 1
+
+var (
+	ErrKeyNotFound   = errors.New("ascon: key not found")
+	ErrKeyNotEnabled = errors.New("ascon: key is not enabled")
+	ErrNoPrimaryKey  = errors.New("ascon: keyring has no primary key")
+)
+
+// Keyring holds a set of Ascon-AEAD128 keys, identified by KeyID,
+// one of which is the primary key used for sealing new messages.
+// Every ciphertext starts with the ID of the key which sealed it,
+// so a key can be rotated by adding a new key and making it primary
+// while the old key stays enabled for opening existing messages.
+//
+// The header is authenticated along with the additional data,
+// so it can't be changed without Open failing.
+//
+// The zero value is an empty keyring.
+// A Keyring is safe for concurrent use.
+type Keyring struct {
+	mu         sync.RWMutex
+	keys       map[KeyID]*keyringEntry
+	primary    KeyID
+	hasPrimary bool
+}
+
+type keyringEntry struct {
+	state KeyState
+	aead  AEAD128
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[KeyID]*keyringEntry)}
+}
+
+// Add adds an enabled key with the given ID.
+// It is an error if the ID is already in use, even by a destroyed key.
+func (k *Keyring) Add(id KeyID, key []byte) error {
+	if len(key) != KeySize {
+		return ErrKeySize
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("ascon: duplicate key ID %d", id)
+	}
+	if k.keys == nil {
+		k.keys = make(map[KeyID]*keyringEntry)
+	}
+	e := &keyringEntry{state: KeyEnabled}
+	e.aead.SetKey(key)
+	k.keys[id] = e
+	return nil
+}
+
+// SetPrimary makes the key with the given ID the one used by Seal.
+// The key must be enabled.
+func (k *Keyring) SetPrimary(id KeyID) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	e, ok := k.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if e.state != KeyEnabled {
+		return ErrKeyNotEnabled
+	}
+	k.primary = id
+	k.hasPrimary = true
+	return nil
+}
+
+// Primary returns the ID of the primary key, if there is one.
+func (k *Keyring) Primary() (KeyID, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary, k.hasPrimary
+}
+
+// State returns the state of the key with the given ID.
+func (k *Keyring) State(id KeyID) (KeyState, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	e, ok := k.keys[id]
+	if !ok {
+		return 0, false
+	}
+	return e.state, true
+}
+
+// IDs returns the IDs of all keys in the keyring, in increasing order.
+func (k *Keyring) IDs() []KeyID {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.sortedIDs()
+}
+
+func (k *Keyring) sortedIDs() []KeyID {
+	ids := make([]KeyID, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Enable re-enables a disabled key.
+func (k *Keyring) Enable(id KeyID) error { return k.setState(id, KeyEnabled) }
+
+// Disable disables a key. The primary key can't be disabled.
+func (k *Keyring) Disable(id KeyID) error { return k.setState(id, KeyDisabled) }
+
+// Destroy erases the key material of a key.
+// The primary key can't be destroyed.
+// Destroying a key can't be undone.
+func (k *Keyring) Destroy(id KeyID) error { return k.setState(id, KeyDestroyed) }
+
+func (k *Keyring) setState(id KeyID, state KeyState) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	e, ok := k.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if e.state == KeyDestroyed && state != KeyDestroyed {
+		return errors.New("ascon: key has been destroyed")
+	}
+	if state != KeyEnabled && k.hasPrimary && k.primary == id {
+		return fmt.Errorf("ascon: can't change state of primary key %d to %v", id, state)
+	}
+	if state == KeyDestroyed {
+		e.aead.Destroy()
+	}
+	e.state = state
+	return nil
+}
+
+func (*Keyring) NonceSize() int { return NonceSize }
+func (*Keyring) Overhead() int  { return KeyringHeaderSize + TagSize }
+
+// Seal encrypts and authenticates a plaintext with the primary key
+// and appends the key header and ciphertext to dst, returning the appended slice.
+func (k *Keyring) Seal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if !k.hasPrimary {
+		return dst, ErrNoPrimaryKey
+	}
+	e := k.keys[k.primary]
+	if len(nonce) != NonceSize {
+		return dst, ErrNonceSize
+	}
+	header := be32append([]byte{keyringVersion}, uint32(k.primary))
+	dst = append(dst, header...)
+	return e.aead.Seal(dst, nonce, plaintext, keyringAD(header, additionalData)), nil
+}
+
+// Open decrypts and authenticates a ciphertext produced by Seal
+// with the key named in its header, which must be enabled,
+// and appends the plaintext to dst, returning the appended slice.
+func (k *Keyring) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < KeyringHeaderSize || ciphertext[0] != keyringVersion {
+		return dst, ErrOpen
+	}
+	id := KeyID(be32dec(ciphertext[1:]))
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	e, ok := k.keys[id]
+	if !ok {
+		return dst, ErrKeyNotFound
+	}
+	if e.state != KeyEnabled {
+		return dst, ErrKeyNotEnabled
+	}
+	header := ciphertext[:KeyringHeaderSize]
+	return e.aead.TryOpen(dst, nonce, ciphertext[KeyringHeaderSize:], keyringAD(header, additionalData))
+}
+
+// keyringAD returns the associated data for a message with the given header.
+// The header has a fixed size, so the concatenation is unambiguous.
+func keyringAD(header, additionalData []byte) []byte {
+	ad := make([]byte, 0, len(header)+len(additionalData))
+	ad = append(ad, header...)
+	return append(ad, additionalData...)
+}
+
+// Binary format of a Keyring:
+//
+//	magic   [4]byte  "asK\x01"
+//	primary uint32   (big endian)
+//	flags   byte     1 if there is a primary key
+//	count   uint32
+//	count times:
+//		id    uint32
+//		state byte
+//		key   [KeySize]byte (omitted for destroyed keys)
+const keyringMagic = "asK\x01"
+
+var errInvalidKeyring = errors.New("ascon: invalid keyring encoding")
+
+// MarshalBinary encodes the keyring, including its key material
+// and the state of each key.
+// The result is secret and must be protected accordingly.
+func (k *Keyring) MarshalBinary() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := k.sortedIDs()
+	b := make([]byte, 0, len(keyringMagic)+9+len(ids)*(5+KeySize))
+	b = append(b, keyringMagic...)
+	b = be32append(b, uint32(k.primary))
+	if k.hasPrimary {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = be32append(b, uint32(len(ids)))
+	for _, id := range ids {
+		e := k.keys[id]
+		b = be32append(b, uint32(id))
+		b = append(b, byte(e.state))
+		if e.state != KeyDestroyed {
+			b = e.aead.appendKey(b)
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the contents of the keyring
+// with a keyring encoded by MarshalBinary.
+func (k *Keyring) UnmarshalBinary(b []byte) error {
+	if len(b) < len(keyringMagic)+9 || string(b[:len(keyringMagic)]) != keyringMagic {
+		return errInvalidKeyring
+	}
+	b = b[len(keyringMagic):]
+	primary := KeyID(be32dec(b))
+	flags := b[4]
+	count := be32dec(b[5:])
+	b = b[9:]
+	if flags > 1 {
+		return errInvalidKeyring
+	}
+	keys := make(map[KeyID]*keyringEntry)
+	for i := uint32(0); i < count; i++ {
+		if len(b) < 5 {
+			return errInvalidKeyring
+		}
+		id := KeyID(be32dec(b))
+		e := &keyringEntry{state: KeyState(b[4])}
+		b = b[5:]
+		switch e.state {
+		case KeyEnabled, KeyDisabled:
+			if len(b) < KeySize {
+				return errInvalidKeyring
+			}
+			e.aead.SetKey(b[:KeySize])
+			b = b[KeySize:]
+		case KeyDestroyed:
+		default:
+			return errInvalidKeyring
+		}
+		if _, dup := keys[id]; dup {
+			return errInvalidKeyring
+		}
+		keys[id] = e
+	}
+	if len(b) != 0 {
+		return errInvalidKeyring
+	}
+	if flags == 1 {
+		if e, ok := keys[primary]; !ok || e.state != KeyEnabled {
+			return errInvalidKeyring
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.primary = primary
+	k.hasPrimary = flags == 1
+	return nil
+}
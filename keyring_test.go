@@ -0,0 +1,171 @@
+package ascon
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	nonce := make([]byte, NonceSize)
+	msg := []byte("attack at dawn")
+	k := NewKeyring()
+	if _, err := k.Seal(nil, nonce, msg, nil); err != ErrNoPrimaryKey {
+		t.Errorf("Seal with no primary key: got error %v, want %v", err, ErrNoPrimaryKey)
+	}
+
+	k.Add(1, mkPattern(KeySize, 0x00))
+	if err := k.SetPrimary(1); err != nil {
+		t.Fatal(err)
+	}
+	old, err := k.Seal(nil, nonce, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(old) != len(msg)+k.Overhead() {
+		t.Errorf("ciphertext has length %d, want %d", len(old), len(msg)+k.Overhead())
+	}
+	if want := []byte{1, 0, 0, 0, 1}; !bytes.Equal(old[:KeyringHeaderSize], want) {
+		t.Errorf("got header %X, want %X", old[:KeyringHeaderSize], want)
+	}
+	a, _ := NewAEAD128(mkPattern(KeySize, 0x00))
+	// the header is authenticated as additional data
+	if want := a.Seal(nil, nonce, msg, old[:KeyringHeaderSize]); !bytes.Equal(old[KeyringHeaderSize:], want) {
+		t.Errorf("ciphertext differs from AEAD128")
+	}
+
+	// Rotate to a new key
+	if err := k.Add(1, mkPattern(KeySize, 0x10)); err == nil {
+		t.Error("Add with a duplicate ID succeeded")
+	}
+	k.Add(0x01020304, mkPattern(KeySize, 0x10))
+	if err := k.SetPrimary(0x01020304); err != nil {
+		t.Fatal(err)
+	}
+	new, _ := k.Seal(nil, nonce, msg, nil)
+	if want := []byte{1, 1, 2, 3, 4}; !bytes.Equal(new[:KeyringHeaderSize], want) {
+		t.Errorf("got header %X, want %X", new[:KeyringHeaderSize], want)
+	}
+	for _, c := range [][]byte{old, new} {
+		if p, err := k.Open(nil, nonce, c, nil); err != nil {
+			t.Errorf("Open failed: %v", err)
+		} else if !bytes.Equal(p, msg) {
+			t.Errorf("Open returned %q, want %q", p, msg)
+		}
+	}
+
+	// Swapping the key ID must not open the message
+	swapped := append([]byte{}, old...)
+	copy(swapped, new[:KeyringHeaderSize])
+	if _, err := k.Open(nil, nonce, swapped, nil); err == nil {
+		t.Error("Open succeeded with the wrong key ID")
+	}
+	// even if the other ID has the same key
+	k.Add(5, mkPattern(KeySize, 0x00))
+	swapped = append([]byte{}, old...)
+	swapped[4] = 5
+	if _, err := k.Open(nil, nonce, swapped, nil); err != ErrOpen {
+		t.Errorf("Open with a modified key ID: got error %v, want %v", err, ErrOpen)
+	}
+	unknown := append([]byte{}, old...)
+	unknown[4] = 9
+	if _, err := k.Open(nil, nonce, unknown, nil); err != ErrKeyNotFound {
+		t.Errorf("Open with unknown key ID: got error %v, want %v", err, ErrKeyNotFound)
+	}
+
+	// Disable and destroy the old key
+	if err := k.Disable(0x01020304); err == nil {
+		t.Error("disabled the primary key")
+	}
+	if err := k.Disable(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Open(nil, nonce, old, nil); err != ErrKeyNotEnabled {
+		t.Errorf("Open with disabled key: got error %v, want %v", err, ErrKeyNotEnabled)
+	}
+	if err := k.Enable(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Open(nil, nonce, old, nil); err != nil {
+		t.Errorf("Open with re-enabled key failed: %v", err)
+	}
+	if err := k.Destroy(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Enable(1); err == nil {
+		t.Error("re-enabled a destroyed key")
+	}
+	if s, _ := k.State(1); s != KeyDestroyed {
+		t.Errorf("got state %v, want %v", s, KeyDestroyed)
+	}
+	if _, err := k.Open(nil, nonce, old, nil); err != ErrKeyNotEnabled {
+		t.Errorf("Open with destroyed key: got error %v, want %v", err, ErrKeyNotEnabled)
+	}
+}
+
+func TestKeyringZeroValue(t *testing.T) {
+	var k Keyring
+	if err := k.Add(1, mkPattern(KeySize, 0x00)); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetPrimary(1); err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, NonceSize)
+	c, err := k.Seal(nil, nonce, []byte("msg"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := k.Open(nil, nonce, c, nil); err != nil || string(p) != "msg" {
+		t.Errorf("Open = %q, %v", p, err)
+	}
+}
+
+func TestKeyringMarshal(t *testing.T) {
+	k := NewKeyring()
+	k.Add(1, mkPattern(KeySize, 0x00))
+	k.Add(2, mkPattern(KeySize, 0x10))
+	k.Add(3, mkPattern(KeySize, 0x20))
+	k.SetPrimary(2)
+	k.Disable(1)
+	k.Destroy(3)
+
+	b, err := k.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "61734B01" + "00000002" + "01" + "00000003" +
+		"00000001" + "02" + "000102030405060708090A0B0C0D0E0F" +
+		"00000002" + "01" + "101112131415161718191A1B1C1D1E1F" +
+		"00000003" + "03"
+	if got := fmt.Sprintf("%X", b); got != want {
+		t.Errorf("MarshalBinary:\ngot  %s\nwant %s", got, want)
+	}
+
+	k2 := NewKeyring()
+	if err := k2.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if b2, _ := k2.MarshalBinary(); !bytes.Equal(b, b2) {
+		t.Errorf("round trip changed the encoding:\ngot  %X\nwant %X", b2, b)
+	}
+	nonce := make([]byte, NonceSize)
+	c, _ := k.Seal(nil, nonce, []byte("hello"), nil)
+	if _, err := k2.Open(nil, nonce, c, nil); err != nil {
+		t.Errorf("unmarshaled keyring can't open message: %v", err)
+	}
+
+	// Every truncation and some corruptions must be rejected
+	for i := 0; i < len(b); i++ {
+		if err := new(Keyring).UnmarshalBinary(b[:i]); err == nil {
+			t.Errorf("UnmarshalBinary accepted truncated input (len %d)", i)
+		}
+	}
+	for _, i := range []int{0, 8, 12, 17} {
+		bad := append([]byte{}, b...)
+		bad[i] ^= 0x40
+		if err := new(Keyring).UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary accepted corrupted byte %d", i)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ascon
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// Key wrapping, in the style of AES-KW with padding (RFC 5649).
+//
+// The key is prefixed with a 4-byte integrity check value and its 4-byte big-endian length,
+// padded with zeroes to a multiple of 8 bytes,
+// and encrypted with SIV128 under the key-encryption key,
+// with no nonce and a fixed string as associated data.
+// The wrapped key is 8 bytes longer than the padded key, plus TagSize bytes of tag.
+//
+// Because SIV128 is deterministic, no nonce is needed,
+// and wrapping the same key twice gives the same result.
+
+// keyWrapICV is the alternative initial value from RFC 5649, section 3.
+var keyWrapICV = [4]byte{0xA6, 0x59, 0x59, 0xA6}
+
+const keyWrapAD = "Ascon key wrap"
+
+// WrapKey encrypts key under the key-encryption key kek,
+// which must be KeySize bytes long.
+// The key may be of any non-zero length less than 2^32 bytes.
+func WrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) == 0 || uint64(len(key)) > 1<<32-1 {
+		return nil, fmt.Errorf("ascon: bad length for wrapped key %d", len(key))
+	}
+	a, err := NewSIV128(kek)
+	if err != nil {
+		return nil, err
+	}
+	padded := (len(key) + 7) &^ 7
+	b := make([]byte, 0, 8+padded)
+	b = append(b, keyWrapICV[:]...)
+	b = be32append(b, uint32(len(key)))
+	b = append(b, key...)
+	b = b[:8+padded] // zero padding
+	out := a.Seal(nil, nil, b, []byte(keyWrapAD))
+	for i := range b {
+		b[i] = 0
+	}
+	return out, nil
+}
+
+// UnwrapKey decrypts a key wrapped by WrapKey,
+// checking its integrity.
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	a, err := NewSIV128(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped) - TagSize
+	if n < 16 || n%8 != 0 {
+		return nil, errors.New("ascon: bad length for wrapped key")
+	}
+	b, err := a.Open(nil, nil, wrapped, []byte(keyWrapAD))
+	if err != nil {
+		return nil, err
+	}
+	// The SIV tag has already authenticated everything,
+	// but check the ICV, length and padding anyway
+	// in case the input was wrapped with a different scheme.
+	keyLen := uint64(be32dec(b[4:]))
+	ok := subtle.ConstantTimeCompare(b[:4], keyWrapICV[:])
+	if keyLen+8 > uint64(len(b)) || keyLen+8+7 < uint64(len(b)) {
+		ok = 0
+	} else {
+		var pad byte
+		for _, x := range b[8+keyLen:] {
+			pad |= x
+		}
+		ok &= subtle.ConstantTimeByteEq(pad, 0)
+	}
+	if ok != 1 {
+		for i := range b {
+			b[i] = 0
+		}
+		return nil, ErrOpen
+	}
+	return b[8 : 8+keyLen], nil
+}
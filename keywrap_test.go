@@ -0,0 +1,131 @@
+package ascon
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+var keyWrapTests = []struct {
+	keyLen     int
+	hexWrapped string
+}{
+	{1, "3218A9770703F8946FE5D865BAC14B4C3F7E4D52E00CF764F5AE3C0790081D10"},
+	{7, "2141D649442940DEFA0FE5D3AA8253CCCCAAFB071D47C8F23214965394704C40"},
+	{8, "406069B7B329B2246A0564CA583086E9EF14A6633E30A21F75F2C87CCC731ACF"},
+	{16, "383A49ABF28529DC726FE90ABE3A44FFCB71682C83EB1743F0B23F76A4EDF3B761513A406551793E"},
+	{20, "88FCC0C9CE0448FB14D2FEA27E06A244AB535B4153D5E982721B5283EB18A140ABA1359179D1632841F8AC74A668BFC4"},
+	{32, "211BE7202E18A9DAF3AD4DCE39F61036895E09D5AE3120C9DEB12AF80298386A22953A2259E10A121965EEA4BFCB61D1A7E82C392933CAC0"},
+}
+
+func TestKeyWrap(t *testing.T) {
+	kek := mkPattern(KeySize, 0x00)
+	for _, tt := range keyWrapTests {
+		key := mkPattern(tt.keyLen, 0x80)
+		wrapped, err := WrapKey(kek, key)
+		if err != nil {
+			t.Errorf("keyLen=%d: unexpected error: %v", tt.keyLen, err)
+			continue
+		}
+		if got := fmt.Sprintf("%X", wrapped); got != tt.hexWrapped {
+			t.Errorf("keyLen=%d: got %s, want %s", tt.keyLen, got, tt.hexWrapped)
+		}
+		if want := (tt.keyLen+7)&^7 + 8 + TagSize; len(wrapped) != want {
+			t.Errorf("keyLen=%d: wrapped key has length %d, want %d", tt.keyLen, len(wrapped), want)
+		}
+		unwrapped, err := UnwrapKey(kek, wrapped)
+		if err != nil {
+			t.Errorf("keyLen=%d: unwrap failed: %v", tt.keyLen, err)
+		} else if !bytes.Equal(unwrapped, key) {
+			t.Errorf("keyLen=%d: unwrapped %X, want %X", tt.keyLen, unwrapped, key)
+		}
+
+		for i := range wrapped {
+			wrapped[i] ^= 0x10
+			if _, err := UnwrapKey(kek, wrapped); err == nil {
+				t.Errorf("keyLen=%d: unwrapped key with byte %d modified", tt.keyLen, i)
+			}
+			wrapped[i] ^= 0x10
+		}
+		if _, err := UnwrapKey(mkPattern(KeySize, 0x01), wrapped); err == nil {
+			t.Errorf("keyLen=%d: unwrapped key with the wrong KEK", tt.keyLen)
+		}
+	}
+}
+
+func TestKeyWrapErrors(t *testing.T) {
+	kek := make([]byte, KeySize)
+	if _, err := WrapKey(kek, nil); err == nil {
+		t.Error("wrapped an empty key")
+	}
+	if _, err := WrapKey(kek[:8], kek); err == nil {
+		t.Error("wrapped with a short KEK")
+	}
+	for _, n := range []int{0, 8, 16 + TagSize + 1, 23 + TagSize} {
+		if _, err := UnwrapKey(kek, make([]byte, n)); err == nil {
+			t.Errorf("unwrapped %d bytes", n)
+		}
+	}
+
+	// A message which is authentic but has bad padding must still be rejected
+	a, _ := NewSIV128(kek)
+	bad := []byte{0xA6, 0x59, 0x59, 0xA6, 0, 0, 0, 1, 0x80, 1, 0, 0, 0, 0, 0, 0}
+	if _, err := UnwrapKey(kek, a.Seal(nil, nil, bad, []byte(keyWrapAD))); err == nil {
+		t.Error("unwrapped key with non-zero padding")
+	}
+	bad = []byte{0xA6, 0x59, 0x59, 0xA6, 0, 0, 0, 9, 0x80, 1, 0, 0, 0, 0, 0, 0}
+	if _, err := UnwrapKey(kek, a.Seal(nil, nil, bad, []byte(keyWrapAD))); err == nil {
+		t.Error("unwrapped key with bad length")
+	}
+}
+
+// keyWrapKatFile was generated by TestGenKatKeyWrap.
+const keyWrapKatFile = "testdata/ascon_keywrap_kat.txt"
+
+func TestKeyWrapKat(t *testing.T) {
+	n := 0
+	readKat(t, keyWrapKatFile, func(kv map[string]string) {
+		n++
+		kek, key := unhex(kv["KEK"]), unhex(kv["Key"])
+		wrapped, err := WrapKey(kek, key)
+		if err != nil {
+			t.Errorf("Count = %s: %v", kv["Count"], err)
+			return
+		}
+		checkHex(t, "Count = "+kv["Count"], wrapped, kv["Wrapped"])
+		if got, err := UnwrapKey(kek, unhex(kv["Wrapped"])); err != nil || !bytes.Equal(got, key) {
+			t.Errorf("Count = %s: UnwrapKey = %X, %v; want %X", kv["Count"], got, err, key)
+		}
+	})
+	if n != 64 {
+		t.Errorf("got %d test vectors from %s, want %d", n, keyWrapKatFile, 64)
+	}
+}
+
+func TestGenKatKeyWrap(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	f, err := os.Create("ascon_keywrap_kat.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	kek := mkPattern(KeySize, 0x00)
+	for i := 1; i <= 64; i++ {
+		key := mkPattern(i, 0x80)
+		wrapped, err := WrapKey(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(w, "Count = %d\n", i)
+		fmt.Fprintf(w, "KEK = %X\n", kek)
+		fmt.Fprintf(w, "Key = %X\n", key)
+		fmt.Fprintf(w, "Wrapped = %X\n", wrapped)
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,122 @@
+package ascon
+
+import "fmt"
+
+// KMAC is a keyed, customizable MAC and XOF in the style of KMAC128
+// from NIST SP 800-185, built on Ascon-CXOF128 instead of cSHAKE128.
+// It implements the hash.Hash interface.
+//
+// The output is Ascon-CXOF128 of
+//
+//	bytepad(encode_string(K), 8) || X || right_encode(L)
+//
+// with the customization string encode_string("KMAC") || encode_string(S),
+// where K is the key, X is the message, S is the customization string,
+// and L is the output length in bits, or 0 in XOF mode.
+// The encodings are as in SP 800-185 and TupleHash128,
+// with the rate of Ascon-CXOF128 (8 bytes) as the bytepad width.
+type KMAC struct {
+	x       Cxof128
+	initial Cxof128 // x after absorbing the key
+	outLen  int
+	reading bool
+}
+
+// MaxKMACCustomizationSize is the longest customization string KMAC accepts,
+// so that the encoded CXOF customization string fits in 256 bytes.
+const MaxKMACCustomizationSize = 256 - 6 - 3
+
+// NewKMAC returns a KMAC with the given key and customization string
+// whose Sum method returns outLen bytes.
+// It returns ErrKeySize if the key is shorter than KeySize bytes,
+// and ErrCustomizationSize if the customization string is
+// longer than MaxKMACCustomizationSize.
+func NewKMAC(key []byte, customizationString string, outLen int) (*KMAC, error) {
+	if len(key) < KeySize {
+		return nil, ErrKeySize
+	}
+	if len(customizationString) > MaxKMACCustomizationSize {
+		return nil, ErrCustomizationSize
+	}
+	if outLen <= 0 {
+		return nil, fmt.Errorf("ascon: invalid KMAC output length %d", outLen)
+	}
+	custom := make([]byte, 0, 256)
+	custom = appendLeftEncode(custom, 4*8)
+	custom = append(custom, "KMAC"...)
+	custom = appendLeftEncode(custom, uint64(len(customizationString))*8)
+	custom = append(custom, customizationString...)
+
+	k := &KMAC{outLen: outLen}
+	k.initial.initBits(custom, len(custom)*8)
+
+	// bytepad(encode_string(K), 8)
+	var buf [9]byte
+	d := &k.initial.digest
+	d.write(appendLeftEncode(buf[:0], BlockSize))
+	d.writeEncodedLength(len(key))
+	d.write(key)
+	if d.len != 0 {
+		d.write(make([]byte, BlockSize-int(d.len)))
+	}
+	k.Reset()
+	return k, nil
+}
+
+// Size returns the length of the output of Sum.
+func (k *KMAC) Size() int { return k.outLen }
+
+// The data rate of the sponge, in bytes.
+func (k *KMAC) BlockSize() int { return BlockSize }
+
+func (k *KMAC) Reset() {
+	k.x = k.initial
+	k.reading = false
+}
+
+// Clone returns a new copy of k.
+func (k *KMAC) Clone() *KMAC {
+	new := *k
+	return &new
+}
+
+// Write absorbs more of the message.
+// It returns ErrWriteAfterRead if output has already been read.
+func (k *KMAC) Write(p []byte) (int, error) {
+	if k.reading {
+		return 0, ErrWriteAfterRead
+	}
+	k.x.digest.write(p)
+	return len(p), nil
+}
+
+// Sum appends the MAC to b and returns the new slice.
+// It does not change the state.
+// It panics if output has already been read with Read.
+func (k *KMAC) Sum(b []byte) []byte {
+	if k.reading {
+		panic("ascon: Sum called after Read")
+	}
+	x := k.x // copy
+	var buf [9]byte
+	x.digest.write(appendRightEncode(buf[:0], uint64(k.outLen)*8))
+	n := len(b)
+	b = append(b, make([]byte, k.outLen)...)
+	x.digest.read(b[n:])
+	x.digest.wipe()
+	return b
+}
+
+// Read reads output in XOF mode, in which the output does not
+// depend on the length requested.
+// The output is unrelated to the output of Sum.
+// After the first Read, the message can't be written to.
+func (k *KMAC) Read(p []byte) (int, error) {
+	if !k.reading {
+		var buf [9]byte
+		k.x.digest.write(appendRightEncode(buf[:0], 0))
+		k.reading = true
+	}
+	k.x.digest.read(p)
+	return len(p), nil
+}
@@ -0,0 +1,149 @@
+package ascon
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"fmt"
+	"hash"
+	"testing"
+)
+
+var _ hash.Hash = (*KMAC)(nil)
+
+var kmacTests = []struct {
+	keyLen int
+	custom string
+	msgLen int
+	outLen int
+	want   string
+}{
+	{16, "", 0, 32, "6C731BA02937CE147FC31096675D0C45FB348B9EABDC83069543FC736ADB6164"},
+	{16, "", 4, 32, "03A3F54A6C613E5C0F45F66EC8F6B8B35F73BF43C83A63AF97F479336F1EF430"},
+	{16, "My Tagged Application", 4, 32, "AFA60AFFC1319DDF585EC2BFCE1E69E808064C51E4BCCCC832A48866BFCD9BB4"},
+	{16, "My Tagged Application", 200, 64, "0FBDB358C85E2BAC2C0586BAE52EB82922A67436233C2E759380D04641F6678248FFFD0088D5A0974AA03AE8C4CBD781DB1EC6F00C9275B26F700C797E70FDAB"},
+	{32, "", 4, 16, "33CB1FC0C6B474F022947B211FBDC481"},
+}
+
+func TestKMAC(t *testing.T) {
+	for _, tt := range kmacTests {
+		key := mkPattern(tt.keyLen, 0x40)
+		msg := mkPattern(tt.msgLen, 0x00)
+		name := fmt.Sprintf("keyLen=%d custom=%q msgLen=%d outLen=%d", tt.keyLen, tt.custom, tt.msgLen, tt.outLen)
+		k, err := NewKMAC(key, tt.custom, tt.outLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Write(msg)
+		got := k.Sum(nil)
+		checkHex(t, name, got, tt.want)
+		if want := refKMAC(key, tt.custom, msg, tt.outLen); !bytes.Equal(got, want) {
+			t.Errorf("%s: got %X, definition gives %X", name, got, want)
+		}
+		if k.Size() != tt.outLen {
+			t.Errorf("%s: Size() = %d", name, k.Size())
+		}
+	}
+}
+
+// refKMAC computes KMAC as documented in kmac.go.
+func refKMAC(key []byte, custom string, msg []byte, outLen int) []byte {
+	z := appendLeftEncode(nil, 32)
+	z = append(z, "KMAC"...)
+	z = appendLeftEncode(z, uint64(len(custom))*8)
+	z = append(z, custom...)
+	x, err := NewCxof128(string(z))
+	if err != nil {
+		panic(err)
+	}
+	pad := appendLeftEncode(nil, 8)
+	pad = appendLeftEncode(pad, uint64(len(key))*8)
+	pad = append(pad, key...)
+	for len(pad)%8 != 0 {
+		pad = append(pad, 0)
+	}
+	x.Write(pad)
+	x.Write(msg)
+	x.Write(appendRightEncode(nil, uint64(outLen)*8))
+	out := make([]byte, outLen)
+	x.Read(out)
+	return out
+}
+
+func TestKMACXOF(t *testing.T) {
+	key := mkPattern(KeySize, 0x40)
+	k, _ := NewKMAC(key, "", 32)
+	k.Write([]byte("abc"))
+	sum := k.Sum(nil)
+	c := k.Clone()
+
+	out := make([]byte, 64)
+	k.Read(out[:5])
+	k.Read(out[5:])
+	checkHex(t, "XOF", out, "45C32C133491475B70420F576EC97DB8ACC6019DF62F43CB3B1CB8F8802CA83D651045DD2E64B48F7532E60B11715CCC92ECC3619D788137905DC160C721B8AA")
+	if bytes.Equal(out[:32], sum) {
+		t.Error("XOF output equals Sum")
+	}
+	if _, err := k.Write(nil); err != ErrWriteAfterRead {
+		t.Errorf("Write after Read: got %v, want ErrWriteAfterRead", err)
+	}
+
+	// the clone is unaffected
+	if got := c.Sum(nil); !bytes.Equal(got, sum) {
+		t.Errorf("Clone: got %X, want %X", got, sum)
+	}
+	k.Reset()
+	k.Write([]byte("abc"))
+	if got := k.Sum(nil); !bytes.Equal(got, sum) {
+		t.Errorf("after Reset: got %X, want %X", got, sum)
+	}
+}
+
+func TestKMACDomains(t *testing.T) {
+	key := mkPattern(KeySize, 0x40)
+	msg := []byte("abc")
+	mac := func(key []byte, custom string, outLen int) []byte {
+		k, err := NewKMAC(key, custom, outLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Write(msg)
+		return k.Sum(nil)
+	}
+	base := mac(key, "", 32)
+	if hmac.Equal(mac(key, "x", 32), base) {
+		t.Error("customization string is ignored")
+	}
+	if hmac.Equal(mac(mkPattern(KeySize, 0x41), "", 32), base) {
+		t.Error("key is ignored")
+	}
+	if hmac.Equal(mac(key, "", 33)[:32], base) {
+		t.Error("output length is ignored")
+	}
+	if hmac.Equal(mac(append(key, 0), "", 32), base) {
+		t.Error("key length is ignored")
+	}
+	// KMAC is not the plain CXOF
+	x, _ := NewCxof128("")
+	x.Write(msg)
+	out := make([]byte, 32)
+	x.Read(out)
+	if hmac.Equal(out, base) {
+		t.Error("KMAC equals Ascon-CXOF128")
+	}
+}
+
+func TestKMACErrors(t *testing.T) {
+	key := mkPattern(KeySize, 0x40)
+	if _, err := NewKMAC(key[:KeySize-1], "", 32); err != ErrKeySize {
+		t.Errorf("short key: got %v, want ErrKeySize", err)
+	}
+	if _, err := NewKMAC(key, string(make([]byte, MaxKMACCustomizationSize)), 32); err != nil {
+		t.Errorf("longest customization string: %v", err)
+	}
+	if _, err := NewKMAC(key, string(make([]byte, MaxKMACCustomizationSize+1)), 32); err != ErrCustomizationSize {
+		t.Errorf("long customization string: got %v, want ErrCustomizationSize", err)
+	}
+	if _, err := NewKMAC(key, "", 0); err == nil {
+		t.Error("NewKMAC accepted an output length of 0")
+	}
+}
@@ -0,0 +1,163 @@
+package ascon
+
+import (
+	"errors"
+	"sync/atomic"
+	"unsafe"
+)
+
+// MaxBlocksPerKey is the largest number of plaintext and associated data blocks
+// which may be processed under a single Ascon-AEAD128 key.
+// The specification allows 2^64 blocks; this is the closest a uint64 can get.
+const MaxBlocksPerKey = 1<<64 - 1
+
+// ErrKeyExhausted is returned by LimitedAEAD128.TrySeal,
+// and Seal panics with it, when a hard limit would be exceeded.
+var ErrKeyExhausted = errors.New("ascon: key usage limit exceeded")
+
+// Limits configures the thresholds enforced by a LimitedAEAD128.
+// A zero field means there is no limit,
+// except that HardBlocks defaults to MaxBlocksPerKey.
+type Limits struct {
+	// Once SoftBlocks blocks or SoftInvocations messages have been sealed,
+	// Rekey is called.
+	SoftBlocks      uint64
+	SoftInvocations uint64
+
+	// Sealing a message which would take the total past HardBlocks blocks
+	// or HardInvocations messages fails with ErrKeyExhausted.
+	HardBlocks      uint64
+	HardInvocations uint64
+
+	// Rekey, if not nil, is called the first time a soft limit is reached.
+	// It is called exactly once, synchronously,
+	// from the Seal which reached the limit,
+	// and is passed the usage counts as of that call.
+	Rekey func(Usage)
+}
+
+// Usage reports how much data has been sealed under a key.
+type Usage struct {
+	Blocks      uint64 // plaintext and associated data blocks, including padding
+	Invocations uint64 // number of calls to Seal
+}
+
+// LimitedAEAD128 is an Ascon-AEAD128 which keeps count of the data it has sealed,
+// so that the key can be replaced before reaching the limits of the specification
+// or the user's own policy.
+// It implements the crypto/cipher.AEAD interface.
+//
+// Unlike AEAD128, all methods are safe for concurrent use.
+// Open is passed through unchanged and does not count towards the limits.
+type LimitedAEAD128 struct {
+	// usage is a *Usage, which is replaced as a whole
+	// so that both counters are updated together.
+	// It is accessed atomically.
+	usage       unsafe.Pointer
+	rekeyCalled uint32
+
+	aead   AEAD128
+	limits Limits
+}
+
+func NewLimitedAEAD128(key []byte, limits Limits) (*LimitedAEAD128, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	if limits.HardBlocks == 0 {
+		limits.HardBlocks = MaxBlocksPerKey
+	}
+	if limits.HardInvocations == 0 {
+		limits.HardInvocations = 1<<64 - 1
+	}
+	a := &LimitedAEAD128{limits: limits, usage: unsafe.Pointer(new(Usage))}
+	a.aead.SetKey(key)
+	return a, nil
+}
+
+func (*LimitedAEAD128) NonceSize() int { return NonceSize }
+func (*LimitedAEAD128) Overhead() int  { return TagSize }
+
+// Usage returns the amount of data sealed so far.
+// Blocks are counted before encryption starts,
+// so the count includes any calls to Seal which are still in progress.
+func (a *LimitedAEAD128) Usage() Usage {
+	return *(*Usage)(atomic.LoadPointer(&a.usage))
+}
+
+// Seal is like AEAD128.Seal.
+// It panics with ErrKeyExhausted if sealing the message would exceed a hard limit.
+func (a *LimitedAEAD128) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	dst, err := a.TrySeal(dst, nonce, plaintext, additionalData)
+	if err != nil {
+		panic(err)
+	}
+	return dst
+}
+
+// TrySeal is like Seal,
+// but returns ErrKeyExhausted instead of panicking
+// if sealing the message would exceed a hard limit,
+// and ErrNonceSize if the nonce is the wrong size.
+// In that case nothing is encrypted and dst is returned unchanged.
+func (a *LimitedAEAD128) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		return dst, ErrNonceSize
+	}
+	u, err := a.reserve(countBlocks(len(plaintext), len(additionalData)))
+	if err != nil {
+		return dst, err
+	}
+	if a.limits.Rekey != nil && a.softLimitReached(u) {
+		if atomic.CompareAndSwapUint32(&a.rekeyCalled, 0, 1) {
+			a.limits.Rekey(u)
+		}
+	}
+	return a.aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+func (a *LimitedAEAD128) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return a.aead.Open(dst, nonce, ciphertext, additionalData)
+}
+
+// TryOpen is like Open, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+func (a *LimitedAEAD128) TryOpen(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return a.aead.TryOpen(dst, nonce, ciphertext, additionalData)
+}
+
+// reserve adds one invocation and n blocks to the counters,
+// unless doing so would exceed a hard limit.
+// Both limits are checked before either counter changes,
+// so a call which fails never affects a concurrent one.
+// It returns the new totals.
+func (a *LimitedAEAD128) reserve(n uint64) (Usage, error) {
+	for {
+		p := atomic.LoadPointer(&a.usage)
+		old := (*Usage)(p)
+		u := Usage{Blocks: old.Blocks + n, Invocations: old.Invocations + 1}
+		if u.Invocations < old.Invocations || u.Invocations > a.limits.HardInvocations ||
+			u.Blocks < old.Blocks || u.Blocks > a.limits.HardBlocks {
+			return u, ErrKeyExhausted
+		}
+		if atomic.CompareAndSwapPointer(&a.usage, p, unsafe.Pointer(&u)) {
+			return u, nil
+		}
+	}
+}
+
+func (a *LimitedAEAD128) softLimitReached(u Usage) bool {
+	return (a.limits.SoftBlocks != 0 && u.Blocks >= a.limits.SoftBlocks) ||
+		(a.limits.SoftInvocations != 0 && u.Invocations >= a.limits.SoftInvocations)
+}
+
+// countBlocks returns the number of 128-bit blocks Ascon-AEAD128
+// absorbs for a message, including the padding blocks.
+func countBlocks(plaintextLen, additionalDataLen int) uint64 {
+	n := uint64(plaintextLen)/16 + 1
+	if additionalDataLen > 0 {
+		// no padding is applied to empty additional data
+		n += uint64(additionalDataLen)/16 + 1
+	}
+	return n
+}
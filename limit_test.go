@@ -0,0 +1,166 @@
+package ascon
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"sync"
+	"testing"
+)
+
+var _ cipher.AEAD = (*LimitedAEAD128)(nil)
+
+func TestCountBlocks(t *testing.T) {
+	tests := []struct {
+		ptLen, adLen int
+		want         uint64
+	}{
+		{0, 0, 1},
+		{15, 0, 1},
+		{16, 0, 2},
+		{0, 1, 2},
+		{0, 16, 3},
+		{32, 17, 5},
+	}
+	for _, tt := range tests {
+		if got := countBlocks(tt.ptLen, tt.adLen); got != tt.want {
+			t.Errorf("countBlocks(%d, %d) = %d, want %d", tt.ptLen, tt.adLen, got, tt.want)
+		}
+	}
+}
+
+func TestLimitedAEAD(t *testing.T) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, NonceSize)
+	msg := make([]byte, 40) // 3 blocks
+
+	var rekeys []Usage
+	a, err := NewLimitedAEAD128(key, Limits{
+		SoftBlocks: 7,
+		HardBlocks: 13,
+		Rekey:      func(u Usage) { rekeys = append(rekeys, u) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := NewAEAD128(key)
+
+	for i := 1; i <= 4; i++ {
+		c, err := a.TrySeal(nil, nonce, msg, nil)
+		if err != nil {
+			t.Fatalf("seal %d: unexpected error: %v", i, err)
+		}
+		if want := plain.Seal(nil, nonce, msg, nil); !bytes.Equal(c, want) {
+			t.Errorf("seal %d: ciphertext differs from AEAD128", i)
+		}
+		if u := a.Usage(); u.Blocks != uint64(3*i) || u.Invocations != uint64(i) {
+			t.Errorf("seal %d: got usage %+v", i, u)
+		}
+	}
+	if len(rekeys) != 1 || rekeys[0] != (Usage{Blocks: 9, Invocations: 3}) {
+		t.Errorf("got rekey calls %+v, want one call at 9 blocks", rekeys)
+	}
+
+	c, err := a.TrySeal([]byte("dst"), nonce, msg, nil)
+	if err != ErrKeyExhausted {
+		t.Errorf("seal past hard limit: got error %v, want %v", err, ErrKeyExhausted)
+	}
+	if string(c) != "dst" {
+		t.Errorf("seal past hard limit: dst was modified")
+	}
+	if u := a.Usage(); u.Blocks != 12 || u.Invocations != 4 {
+		t.Errorf("failed seal changed usage to %+v", u)
+	}
+	// A smaller message still fits
+	if _, err := a.TrySeal(nil, nonce, nil, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != ErrKeyExhausted {
+				t.Errorf("Seal past hard limit: got panic %v, want %v", r, ErrKeyExhausted)
+			}
+		}()
+		a.Seal(nil, nonce, nil, nil)
+	}()
+}
+
+func TestLimitedAEADConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 8, 100
+	a, _ := NewLimitedAEAD128(make([]byte, KeySize), Limits{
+		HardInvocations: goroutines * perGoroutine / 2,
+	})
+	nonce := make([]byte, NonceSize)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				if _, err := a.TrySeal(nil, nonce, nil, nil); err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != goroutines*perGoroutine/2 {
+		t.Errorf("%d seals succeeded, want %d", succeeded, goroutines*perGoroutine/2)
+	}
+	if u := a.Usage(); u.Invocations != uint64(succeeded) || u.Blocks != uint64(succeeded) {
+		t.Errorf("got usage %+v, want %d", u, succeeded)
+	}
+}
+
+func TestLimitedAEADConcurrentFailures(t *testing.T) {
+	// Seals which fail the block limit must not use up invocations
+	// which other seals are entitled to, even briefly.
+	const goroutines, perGoroutine = 4, 50
+	a, _ := NewLimitedAEAD128(make([]byte, KeySize), Limits{
+		HardInvocations: goroutines * perGoroutine,
+		HardBlocks:      1000,
+	})
+	nonce := make([]byte, NonceSize)
+	big := make([]byte, 1000*16)
+	done := make(chan bool)
+	var failers sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		failers.Add(1)
+		go func() {
+			defer failers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := a.TrySeal(nil, nonce, big, nil); err != ErrKeyExhausted {
+					t.Errorf("sealing %d bytes: got %v, want ErrKeyExhausted", len(big), err)
+					return
+				}
+			}
+		}()
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				if _, err := a.TrySeal(nil, nonce, nil, nil); err != nil {
+					t.Errorf("seal %d: %v", i, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	failers.Wait()
+	if u := a.Usage(); u.Invocations != goroutines*perGoroutine || u.Blocks != goroutines*perGoroutine {
+		t.Errorf("got usage %+v, want %d", u, goroutines*perGoroutine)
+	}
+}
@@ -0,0 +1,167 @@
+package ascon
+
+import "errors"
+
+// The serialized hash states begin with a magic string identifying the algorithm,
+// followed by a version byte.
+// Hash256, Xof128 and Cxof128 implement encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler so that a long hash can be checkpointed and resumed,
+// like the hashes in the standard library.
+const (
+	magicHash256 = "asc\x01"
+	magicXof128  = "asc\x02"
+	magicCxof128 = "asc\x03"
+
+	marshalVersion = 1
+
+	// magic || version || s || buf || len || bits || flags
+	digestMarshaledSize = len(magicHash256) + 1 + stateSize + BlockSize + 3
+	// digest || initialState
+	cxofMarshaledSize = digestMarshaledSize + stateSize
+)
+
+const (
+	flagInitialized = 1 << iota
+	flagDoneWriting
+	flagInitialState
+)
+
+var (
+	errMarshalID      = errors.New("ascon: invalid hash state identifier")
+	errMarshalVersion = errors.New("ascon: unsupported hash state version")
+	errMarshalSize    = errors.New("ascon: invalid hash state size")
+	errMarshalState   = errors.New("ascon: invalid hash state")
+)
+
+func (h *Hash256) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, digestMarshaledSize)
+	return h.digest.appendBinary(b, magicHash256, 0), nil
+}
+
+func (h *Hash256) UnmarshalBinary(b []byte) error {
+	var d digest
+	if _, err := d.unmarshalBinary(b, magicHash256, digestMarshaledSize); err != nil {
+		return err
+	}
+	if d.doneWriting {
+		return errMarshalState
+	}
+	h.digest = d
+	return nil
+}
+
+func (x *Xof128) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, digestMarshaledSize)
+	return x.digest.appendBinary(b, magicXof128, 0), nil
+}
+
+func (x *Xof128) UnmarshalBinary(b []byte) error {
+	var d digest
+	if _, err := d.unmarshalBinary(b, magicXof128, digestMarshaledSize); err != nil {
+		return err
+	}
+	x.digest = d
+	return nil
+}
+
+func (x *Cxof128) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, cxofMarshaledSize)
+	if x.initialState == nil {
+		b = x.digest.appendBinary(b, magicCxof128, 0)
+		return append(b, make([]byte, stateSize)...), nil
+	}
+	b = x.digest.appendBinary(b, magicCxof128, flagInitialState)
+	for _, w := range x.initialState {
+		b = be64append(b, w)
+	}
+	return b, nil
+}
+
+func (x *Cxof128) UnmarshalBinary(b []byte) error {
+	var d digest
+	flags, err := d.unmarshalBinary(b, magicCxof128, cxofMarshaledSize)
+	if err != nil {
+		return err
+	}
+	// The initial state is needed by Reset, and is only missing
+	// from the zero value
+	if d.initialized != (flags&flagInitialState != 0) {
+		return errMarshalState
+	}
+	var init *state
+	b = b[digestMarshaledSize:]
+	if d.initialized {
+		init = new(state)
+		for i := range init {
+			init[i] = be64dec(b[8*i:])
+		}
+	} else {
+		for _, c := range b {
+			if c != 0 {
+				return errMarshalState
+			}
+		}
+	}
+	x.digest = d
+	x.initialState = init
+	return nil
+}
+
+// appendBinary appends the serialized digest to b,
+// with the given magic string and extra flags.
+func (d *digest) appendBinary(b []byte, magic string, flags byte) []byte {
+	b = append(b, magic...)
+	b = append(b, marshalVersion)
+	for _, w := range d.s {
+		b = be64append(b, w)
+	}
+	b = append(b, d.buf[:]...)
+	if d.initialized {
+		flags |= flagInitialized
+	}
+	if d.doneWriting {
+		flags |= flagDoneWriting
+	}
+	return append(b, d.len, d.bits, flags)
+}
+
+// unmarshalBinary decodes a digest serialized by appendBinary
+// from a buffer of exactly size bytes, and returns its flags.
+// It checks that the digest upholds the invariants of write and read,
+// so that corrupted input can't cause a panic later.
+func (d *digest) unmarshalBinary(b []byte, magic string, size int) (flags byte, err error) {
+	if len(b) < len(magic) || string(b[:len(magic)]) != magic {
+		return 0, errMarshalID
+	}
+	if len(b) < len(magic)+1 || b[len(magic)] != marshalVersion {
+		return 0, errMarshalVersion
+	}
+	if len(b) != size {
+		return 0, errMarshalSize
+	}
+	b = b[len(magic)+1:]
+	var x digest
+	for i := range x.s {
+		x.s[i] = be64dec(b[8*i:])
+	}
+	b = b[stateSize:]
+	copy(x.buf[:], b)
+	x.len, x.bits, flags = b[BlockSize], b[BlockSize+1], b[BlockSize+2]
+	x.initialized = flags&flagInitialized != 0
+	x.doneWriting = flags&flagDoneWriting != 0
+
+	switch {
+	case flags&^(flagInitialized|flagDoneWriting|flagInitialState) != 0:
+		return 0, errMarshalState
+	case x.bits >= 8:
+		return 0, errMarshalState
+	case x.doneWriting && (x.bits != 0 || x.len > BlockSize):
+		return 0, errMarshalState
+	case !x.doneWriting && x.len >= BlockSize:
+		return 0, errMarshalState
+	case !x.initialized && x != (digest{}):
+		return 0, errMarshalState
+	}
+	*d = x
+	return flags, nil
+}
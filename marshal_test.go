@@ -0,0 +1,201 @@
+package ascon
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Hash256)(nil)
+	_ encoding.BinaryUnmarshaler = (*Hash256)(nil)
+	_ encoding.BinaryMarshaler   = (*Xof128)(nil)
+	_ encoding.BinaryUnmarshaler = (*Xof128)(nil)
+	_ encoding.BinaryMarshaler   = (*Cxof128)(nil)
+	_ encoding.BinaryUnmarshaler = (*Cxof128)(nil)
+)
+
+func TestMarshalHash(t *testing.T) {
+	msg := mkPattern(100, 0x00)
+	want := NewHash256()
+	want.Write(msg)
+	wantSum := want.Sum(nil)
+	for i := 0; i <= len(msg); i++ {
+		h := NewHash256()
+		h.Write(msg[:i])
+		state, err := h.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h2 Hash256
+		if err := h2.UnmarshalBinary(state); err != nil {
+			t.Fatalf("split %d: %v", i, err)
+		}
+		h2.Write(msg[i:])
+		if got := h2.Sum(nil); !bytes.Equal(got, wantSum) {
+			t.Errorf("split %d: got %X, want %X", i, got, wantSum)
+		}
+	}
+
+	// a zero value round trips
+	var zero, h Hash256
+	state, _ := zero.MarshalBinary()
+	if err := h.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	h.Write(msg)
+	if got := h.Sum(nil); !bytes.Equal(got, wantSum) {
+		t.Errorf("zero value: got %X, want %X", got, wantSum)
+	}
+
+	// partial bytes are preserved
+	h.Reset()
+	h.WriteBits([]byte{0xff}, 3)
+	state, _ = h.MarshalBinary()
+	var h2 Hash256
+	if err := h2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h2.Sum(nil), h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("WriteBits: got %X, want %X", got, want)
+	}
+	if err := h2.WriteBits([]byte{0}, 8); err != ErrWriteAfterBits {
+		t.Errorf("WriteBits after a partial byte: got %v, want ErrWriteAfterBits", err)
+	}
+}
+
+func TestMarshalXof(t *testing.T) {
+	msg := mkPattern(20, 0x00)
+	want := make([]byte, 40)
+	x := NewXof128()
+	x.Write(msg)
+	x.Read(want)
+
+	// checkpoint while writing
+	x.Reset()
+	x.Write(msg[:13])
+	state, _ := x.MarshalBinary()
+	var x2 Xof128
+	if err := x2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	x2.Write(msg[13:])
+	got := make([]byte, 40)
+	x2.Read(got)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %X, want %X", got, want)
+	}
+
+	// checkpoint while reading
+	x.Reset()
+	x.Write(msg)
+	x.Read(got[:11])
+	state, _ = x.MarshalBinary()
+	if err := x2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	x2.Read(got[11:])
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %X, want %X", got, want)
+	}
+	if _, err := x2.Write(msg); err != ErrWriteAfterRead {
+		t.Errorf("Write after Read: got %v, want ErrWriteAfterRead", err)
+	}
+}
+
+func TestMarshalCxof(t *testing.T) {
+	msg := mkPattern(20, 0x00)
+	x, _ := NewCxof128("custom")
+	x.Write(msg[:5])
+	state, err := x.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Write(msg[5:])
+	want := make([]byte, 32)
+	x.Read(want)
+
+	var x2 Cxof128
+	if err := x2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	x2.Write(msg[5:])
+	got := make([]byte, 32)
+	x2.Read(got)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %X, want %X", got, want)
+	}
+
+	// Reset returns to the customized initial state
+	x.Reset()
+	x2.Reset()
+	x.Read(want)
+	x2.Read(got)
+	if !bytes.Equal(got, want) {
+		t.Errorf("after Reset: got %X, want %X", got, want)
+	}
+
+	// a zero value round trips
+	var zero Cxof128
+	state, _ = zero.MarshalBinary()
+	if err := x2.UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	x2.Read(got)
+	if x2.initialState == nil {
+		t.Error("zero value was not initialized on use")
+	}
+	x, _ = NewCxof128("")
+	x.Read(want)
+	if !bytes.Equal(got, want) {
+		t.Errorf("zero value: got %X, want %X", got, want)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	h := NewHash256()
+	h.Write([]byte("abc"))
+	hs, _ := h.MarshalBinary()
+	x := NewXof128()
+	xs, _ := x.MarshalBinary()
+	c, _ := NewCxof128("abc")
+	cs, _ := c.MarshalBinary()
+
+	corrupt := func(b []byte, i int, v byte) []byte {
+		b = append([]byte(nil), b...)
+		b[i] = v
+		return b
+	}
+	const (
+		offLen   = digestMarshaledSize - 3
+		offBits  = digestMarshaledSize - 2
+		offFlags = digestMarshaledSize - 1
+	)
+	bad := []struct {
+		name string
+		u    encoding.BinaryUnmarshaler
+		b    []byte
+	}{
+		{"empty", new(Hash256), nil},
+		{"wrong magic", new(Hash256), xs},
+		{"wrong magic", new(Xof128), cs},
+		{"wrong magic", new(Cxof128), hs},
+		{"bad version", new(Hash256), corrupt(hs, 4, 2)},
+		{"short", new(Hash256), hs[:len(hs)-1]},
+		{"long", new(Xof128), append(xs[:len(xs):len(xs)], 0)},
+		{"short", new(Cxof128), cs[:digestMarshaledSize]},
+		{"len too big", new(Hash256), corrupt(hs, offLen, BlockSize)},
+		{"bits too big", new(Hash256), corrupt(hs, offBits, 8)},
+		{"unknown flag", new(Xof128), corrupt(xs, offFlags, 0x80)},
+		{"done writing", new(Hash256), corrupt(hs, offFlags, flagInitialized|flagDoneWriting)},
+		{"bits after read", new(Xof128), corrupt(corrupt(xs, offFlags, flagInitialized|flagDoneWriting), offBits, 1)},
+		{"uninitialized", new(Hash256), corrupt(hs, offFlags, 0)},
+		{"no initial state", new(Cxof128), corrupt(cs, offFlags, flagInitialized)},
+		{"unexpected initial state", new(Cxof128), corrupt(cs, offFlags, flagInitialState)},
+	}
+	for _, tt := range bad {
+		if err := tt.u.UnmarshalBinary(tt.b); err == nil {
+			t.Errorf("%T: %s: UnmarshalBinary succeeded", tt.u, tt.name)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package ascon
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// A NonceSource generates nonces.
+// Implementations must never return the same nonce twice.
+type NonceSource interface {
+	// Nonce fills nonce with a fresh nonce.
+	Nonce(nonce []byte) error
+}
+
+// ErrNonceExhausted is returned by counter-based nonce sources
+// when every value of the counter has been used.
+var ErrNonceExhausted = errors.New("ascon: nonce counter exhausted")
+
+// RandomNonces generates random nonces.
+// Random nonces are only safe if few enough messages are sealed under one key
+// that collisions are unlikely; for a 128-bit nonce that is well below 2^64 messages.
+type RandomNonces struct {
+	// Rand is the source of randomness.
+	// If nil, crypto/rand.Reader is used.
+	Rand io.Reader
+}
+
+func (r RandomNonces) Nonce(nonce []byte) error {
+	rd := r.Rand
+	if rd == nil {
+		rd = rand.Reader
+	}
+	_, err := io.ReadFull(rd, nonce)
+	return err
+}
+
+// CounterNonces generates NonceSize-byte nonces
+// consisting of a fixed prefix followed by a big-endian counter,
+// starting at zero.
+// The counter fills the rest of the nonce, but is at most 64 bits wide.
+// It is safe for concurrent use.
+type CounterNonces struct {
+	mu        sync.Mutex
+	prefix    []byte
+	next      uint64
+	max       uint64
+	exhausted bool
+}
+
+// NewCounterNonces returns a counter with the given prefix.
+// The prefix may be at most NonceSize-4 bytes long,
+// leaving room for a counter of at least 32 bits.
+func NewCounterNonces(prefix []byte) (*CounterNonces, error) {
+	if len(prefix) > NonceSize-4 {
+		return nil, fmt.Errorf("ascon: nonce prefix too long (len %d)", len(prefix))
+	}
+	return &CounterNonces{
+		prefix: append([]byte(nil), prefix...),
+		max:    counterMax(len(prefix)),
+	}, nil
+}
+
+func (c *CounterNonces) Nonce(nonce []byte) error {
+	if len(nonce) != NonceSize {
+		return ErrNonceSize
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.exhausted {
+		return ErrNonceExhausted
+	}
+	putCounterNonce(nonce, c.prefix, c.next)
+	if c.next == c.max {
+		c.exhausted = true
+	} else {
+		c.next++
+	}
+	return nil
+}
+
+// counterMax returns the largest counter which fits after a prefix of the given length.
+func counterMax(prefixLen int) uint64 {
+	bits := uint(NonceSize-prefixLen) * 8
+	if bits >= 64 {
+		return 1<<64 - 1
+	}
+	return 1<<bits - 1
+}
+
+func putCounterNonce(nonce, prefix []byte, counter uint64) {
+	n := copy(nonce, prefix)
+	for i := len(nonce) - 1; i >= n; i-- {
+		nonce[i] = byte(counter)
+		counter >>= 8
+	}
+}
+
+// FileNonces is like CounterNonces, but persists the counter in a file
+// so that values are never reused when the program restarts.
+//
+// Rather than writing the file on every call,
+// FileNonces reserves a range of counter values at a time
+// and records the end of the range in the file before handing out any of them.
+// Values which are reserved but not used before the program exits are skipped.
+// The file also records the prefix, which must match when it is reopened.
+//
+// It is safe for concurrent use within a process,
+// but the file must not be shared by processes running at the same time.
+type FileNonces struct {
+	mu        sync.Mutex
+	path      string
+	prefix    []byte
+	reserve   uint64
+	next      uint64 // next counter to hand out
+	limit     uint64 // first counter not yet reserved
+	max       uint64
+	exhausted bool
+}
+
+// File format:
+//
+//	magic     [4]byte  "asN\x01"
+//	prefixLen byte
+//	prefix    [prefixLen]byte
+//	limit     uint64 (big endian)
+const fileNoncesMagic = "asN\x01"
+
+// OpenFileNonces opens or creates the counter file at path
+// and reserves the first range of reserve values.
+func OpenFileNonces(path string, prefix []byte, reserve uint64) (*FileNonces, error) {
+	if len(prefix) > NonceSize-4 {
+		return nil, fmt.Errorf("ascon: nonce prefix too long (len %d)", len(prefix))
+	}
+	if reserve == 0 {
+		return nil, errors.New("ascon: nonce reservation must be positive")
+	}
+	f := &FileNonces{
+		path:    path,
+		prefix:  append([]byte(nil), prefix...),
+		reserve: reserve,
+		max:     counterMax(len(prefix)),
+	}
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		// start from zero
+	case err != nil:
+		return nil, err
+	default:
+		if f.next, err = f.decode(b); err != nil {
+			return nil, err
+		}
+	}
+	f.limit = f.next
+	if err := f.reserveLocked(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileNonces) Nonce(nonce []byte) error {
+	if len(nonce) != NonceSize {
+		return ErrNonceSize
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.next == f.limit {
+		if err := f.reserveLocked(); err != nil {
+			return err
+		}
+	}
+	putCounterNonce(nonce, f.prefix, f.next)
+	f.next++
+	return nil
+}
+
+// reserveLocked extends the reserved range and persists it.
+// f.mu must be held.
+func (f *FileNonces) reserveLocked() error {
+	if f.exhausted || f.limit == f.max {
+		// the counter value max itself is never handed out,
+		// since the limit is exclusive
+		f.exhausted = true
+		return ErrNonceExhausted
+	}
+	limit := f.limit + f.reserve
+	if limit < f.limit || limit > f.max {
+		limit = f.max
+	}
+	if err := f.write(limit); err != nil {
+		return err
+	}
+	f.limit = limit
+	return nil
+}
+
+func (f *FileNonces) decode(b []byte) (uint64, error) {
+	errCorrupt := fmt.Errorf("ascon: corrupt nonce file %s", f.path)
+	if len(b) < len(fileNoncesMagic)+1 || string(b[:len(fileNoncesMagic)]) != fileNoncesMagic {
+		return 0, errCorrupt
+	}
+	b = b[len(fileNoncesMagic):]
+	n := int(b[0])
+	b = b[1:]
+	if len(b) != n+8 {
+		return 0, errCorrupt
+	}
+	if !bytes.Equal(b[:n], f.prefix) {
+		return 0, fmt.Errorf("ascon: nonce file %s has a different prefix", f.path)
+	}
+	limit := be64dec(b[n:])
+	if limit > f.max {
+		return 0, errCorrupt
+	}
+	return limit, nil
+}
+
+// write atomically replaces the file with one recording limit.
+func (f *FileNonces) write(limit uint64) error {
+	b := append([]byte(fileNoncesMagic), byte(len(f.prefix)))
+	b = append(b, f.prefix...)
+	b = be64append(b, limit)
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+	// The rename isn't durable until the directory is synced.
+	// Without this, a crash could roll the file back to an older limit.
+	return syncDir(filepath.Dir(f.path))
+}
+
+// syncDir flushes the directory entries of dir to stable storage.
+// Windows doesn't support syncing directories, and doesn't need to.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// NonceAEAD seals each message with a nonce from a NonceSource
+// and prepends the nonce to the ciphertext,
+// so that callers never have to handle nonces themselves.
+type NonceAEAD struct {
+	aead   cipher.AEAD
+	nonces NonceSource
+}
+
+// NewNonceAEAD returns a NonceAEAD which seals messages with aead,
+// using nonces of length aead.NonceSize() taken from nonces.
+func NewNonceAEAD(aead cipher.AEAD, nonces NonceSource) *NonceAEAD {
+	return &NonceAEAD{aead: aead, nonces: nonces}
+}
+
+// Overhead returns the difference between the lengths of a ciphertext and its plaintext.
+func (a *NonceAEAD) Overhead() int { return a.aead.NonceSize() + a.aead.Overhead() }
+
+// Seal encrypts and authenticates a plaintext with a fresh nonce
+// and appends the nonce and ciphertext to dst, returning the appended slice.
+// It only fails if the nonce source does.
+func (a *NonceAEAD) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	n := a.aead.NonceSize()
+	dstLen := len(dst)
+	dst = append(dst, make([]byte, n)...)
+	nonce := dst[dstLen:]
+	if err := a.nonces.Nonce(nonce); err != nil {
+		return dst[:dstLen], err
+	}
+	return a.aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts and authenticates a ciphertext produced by Seal
+// and appends the plaintext to dst, returning the appended slice.
+func (a *NonceAEAD) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	n := a.aead.NonceSize()
+	if len(ciphertext) < n {
+		return dst, ErrOpen
+	}
+	return a.aead.Open(dst, ciphertext[:n], ciphertext[n:], additionalData)
+}
@@ -0,0 +1,138 @@
+package ascon
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRandomNonces(t *testing.T) {
+	src := RandomNonces{Rand: bytes.NewReader(mkPattern(2*NonceSize, 0))}
+	nonce := make([]byte, NonceSize)
+	for i := 0; i < 2; i++ {
+		if err := src.Nonce(nonce); err != nil {
+			t.Fatal(err)
+		}
+		if want := mkPattern(NonceSize, byte(i*NonceSize)); !bytes.Equal(nonce, want) {
+			t.Errorf("got nonce %X, want %X", nonce, want)
+		}
+	}
+	if err := src.Nonce(nonce); err == nil {
+		t.Error("expected an error when the reader runs dry")
+	}
+}
+
+func TestCounterNonces(t *testing.T) {
+	c, err := NewCounterNonces([]byte("prefix......"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, NonceSize)
+	for i := 0; i < 3; i++ {
+		c.Nonce(nonce)
+		if want := fmt.Sprintf("prefix......\x00\x00\x00%c", i); string(nonce) != want {
+			t.Errorf("got nonce %q, want %q", nonce, want)
+		}
+	}
+
+	// skip to the end
+	c.next = 1<<32 - 2
+	if err := c.Nonce(nonce); err != nil || string(nonce[12:]) != "\xff\xff\xff\xfe" {
+		t.Errorf("got nonce %X, err %v", nonce, err)
+	}
+	if err := c.Nonce(nonce); err != nil || string(nonce[12:]) != "\xff\xff\xff\xff" {
+		t.Errorf("got nonce %X, err %v", nonce, err)
+	}
+	if err := c.Nonce(nonce); err != ErrNonceExhausted {
+		t.Errorf("got error %v, want %v", err, ErrNonceExhausted)
+	}
+
+	if _, err := NewCounterNonces(make([]byte, NonceSize-3)); err == nil {
+		t.Error("accepted a prefix which leaves a 24-bit counter")
+	}
+	c, _ = NewCounterNonces(nil)
+	if c.max != 1<<64-1 {
+		t.Errorf("counter without a prefix has max %x, want %x", c.max, uint64(1<<64-1))
+	}
+}
+
+func TestFileNonces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ascon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nonces")
+	prefix := []byte("host")
+
+	seen := make(map[string]bool)
+	nonce := make([]byte, NonceSize)
+	for run := 0; run < 3; run++ {
+		f, err := OpenFileNonces(path, prefix, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 15; i++ {
+			if err := f.Nonce(nonce); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(nonce, prefix) {
+				t.Fatalf("nonce %X lacks prefix", nonce)
+			}
+			if seen[string(nonce)] {
+				t.Fatalf("run %d: nonce %X reused", run, nonce)
+			}
+			seen[string(nonce)] = true
+		}
+		// 15 used, 20 reserved; the next run starts at 20*(run+1)
+		if f.limit != uint64(20*(run+1)) {
+			t.Errorf("run %d: limit = %d, want %d", run, f.limit, 20*(run+1))
+		}
+	}
+
+	if _, err := OpenFileNonces(path, []byte("other"), 10); err == nil {
+		t.Error("reopened nonce file with a different prefix")
+	}
+	ioutil.WriteFile(path, []byte("garbage"), 0600)
+	if _, err := OpenFileNonces(path, prefix, 10); err == nil {
+		t.Error("opened corrupt nonce file")
+	}
+}
+
+func TestNonceAEAD(t *testing.T) {
+	a, _ := NewAEAD128(make([]byte, KeySize))
+	c, _ := NewCounterNonces(nil)
+	na := NewNonceAEAD(a, c)
+	msg := []byte("attack at dawn")
+	ad := []byte("ad")
+
+	box1, err := na.Seal([]byte("dst"), msg, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box2, _ := na.Seal(nil, msg, ad)
+	if len(box1) != 3+len(msg)+na.Overhead() {
+		t.Errorf("got length %d, want %d", len(box1), 3+len(msg)+na.Overhead())
+	}
+	box1 = box1[3:]
+	if !bytes.Equal(box1[:NonceSize], make([]byte, NonceSize)) || box2[NonceSize-1] != 1 {
+		t.Errorf("unexpected nonces %X, %X", box1[:NonceSize], box2[:NonceSize])
+	}
+	if want := a.Seal(nil, box1[:NonceSize], msg, ad); !bytes.Equal(box1[NonceSize:], want) {
+		t.Error("ciphertext differs from AEAD128")
+	}
+	for _, box := range [][]byte{box1, box2} {
+		p, err := na.Open(nil, box, ad)
+		if err != nil {
+			t.Errorf("Open failed: %v", err)
+		} else if !bytes.Equal(p, msg) {
+			t.Errorf("Open returned %q, want %q", p, msg)
+		}
+	}
+	if _, err := na.Open(nil, box1[:NonceSize-1], ad); err == nil {
+		t.Error("Open succeeded with a truncated box")
+	}
+}
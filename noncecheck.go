@@ -0,0 +1,185 @@
+package ascon
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrNonceReuse is returned by NonceCheckAEAD128.TrySeal,
+// and Seal panics with it, when a nonce is used twice.
+var ErrNonceReuse = errors.New("ascon: nonce reused")
+
+// NonceReuseMode selects what a NonceCheckAEAD128 does when a nonce repeats.
+type NonceReuseMode int
+
+const (
+	// Refuse to seal the message. This is the default.
+	NonceReuseFail NonceReuseMode = iota
+	// Log the reuse and seal the message anyway.
+	// Useful for finding reuse in replays of production traffic.
+	NonceReuseLog
+)
+
+// NonceCheckOptions configures a NonceCheckAEAD128.
+type NonceCheckOptions struct {
+	Mode NonceReuseMode
+
+	// OnReuse, if not nil, is called with each repeated nonce,
+	// before Seal fails or logs it.
+	// The nonce must not be retained after OnReuse returns.
+	OnReuse func(nonce []byte)
+
+	// Logger is used to report reuse in NonceReuseLog mode.
+	// If nil, the standard logger is used.
+	Logger *log.Logger
+
+	// FilterBits, if not zero, replaces the exact set of nonces
+	// with a Bloom filter of that many bits, bounding memory use.
+	// A filter can report false positives:
+	// size it to keep them rare, or use NonceReuseLog mode.
+	// A filter with n nonces and k hashes has a false positive rate of about
+	// (1 - e^(-kn/FilterBits))^k.
+	FilterBits uint64
+	// FilterHashes is the number of bits set per nonce in the filter.
+	// If zero, 7 is used.
+	FilterHashes int
+}
+
+// NonceCheckAEAD128 is an Ascon-AEAD128 which remembers every nonce
+// passed to Seal and detects when one is reused.
+// It is intended for development and auditing:
+// it costs memory for each message and a lock on each Seal.
+// It implements the crypto/cipher.AEAD interface.
+//
+// Apart from SetKey, all methods are safe for concurrent use.
+type NonceCheckAEAD128 struct {
+	aead AEAD128
+	opts NonceCheckOptions
+
+	mu     sync.Mutex
+	seen   map[[NonceSize]byte]struct{}
+	filter []uint64
+	count  uint64
+}
+
+func NewNonceCheckAEAD128(key []byte, opts NonceCheckOptions) (*NonceCheckAEAD128, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	if opts.FilterHashes < 0 {
+		return nil, errors.New("ascon: negative number of filter hashes")
+	}
+	if opts.FilterHashes == 0 {
+		opts.FilterHashes = 7
+	}
+	a := &NonceCheckAEAD128{opts: opts}
+	a.SetKey(key)
+	return a, nil
+}
+
+// SetKey sets the key to a new value and forgets all previously seen nonces.
+// This method is not safe for concurrent use with other methods.
+func (a *NonceCheckAEAD128) SetKey(key []byte) {
+	a.aead.SetKey(key)
+	if a.opts.FilterBits != 0 {
+		a.filter = make([]uint64, (a.opts.FilterBits+63)/64)
+	} else {
+		a.seen = make(map[[NonceSize]byte]struct{})
+	}
+	a.count = 0
+}
+
+// Count returns the number of distinct nonces seen by Seal under the current key.
+// With a filter, nonces which were falsely reported as reused are not counted.
+func (a *NonceCheckAEAD128) Count() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+func (*NonceCheckAEAD128) NonceSize() int { return NonceSize }
+func (*NonceCheckAEAD128) Overhead() int  { return TagSize }
+
+// Seal is like AEAD128.Seal.
+// In NonceReuseFail mode, it panics with ErrNonceReuse if the nonce has been seen before.
+func (a *NonceCheckAEAD128) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	dst, err := a.TrySeal(dst, nonce, plaintext, additionalData)
+	if err != nil {
+		panic(err)
+	}
+	return dst
+}
+
+// TrySeal is like Seal, but returns ErrNonceReuse instead of panicking,
+// and ErrNonceSize if the nonce is the wrong size.
+// In that case nothing is encrypted and dst is returned unchanged.
+func (a *NonceCheckAEAD128) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		return dst, ErrNonceSize
+	}
+	if a.record(nonce) {
+		if a.opts.OnReuse != nil {
+			a.opts.OnReuse(nonce)
+		}
+		if a.opts.Mode != NonceReuseLog {
+			return dst, ErrNonceReuse
+		}
+		if a.opts.Logger != nil {
+			a.opts.Logger.Printf("ascon: nonce %x reused", nonce)
+		} else {
+			log.Printf("ascon: nonce %x reused", nonce)
+		}
+	}
+	return a.aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+func (a *NonceCheckAEAD128) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return a.aead.Open(dst, nonce, ciphertext, additionalData)
+}
+
+// TryOpen is like Open, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+func (a *NonceCheckAEAD128) TryOpen(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	return a.aead.TryOpen(dst, nonce, ciphertext, additionalData)
+}
+
+// record adds the nonce to the set and reports whether it was already present.
+func (a *NonceCheckAEAD128) record(nonce []byte) (seen bool) {
+	var n [NonceSize]byte
+	copy(n[:], nonce)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.filter != nil {
+		seen = a.filterAdd(&n)
+	} else {
+		_, seen = a.seen[n]
+		a.seen[n] = struct{}{}
+	}
+	if !seen {
+		a.count++
+	}
+	return seen
+}
+
+// filterAdd sets the nonce's bits in the Bloom filter
+// and reports whether they were all set already.
+// a.mu must be held.
+func (a *NonceCheckAEAD128) filterAdd(n *[NonceSize]byte) bool {
+	// Nonces are often counters, so mix them up with a few rounds of the permutation
+	// and use double hashing to derive the bit positions.
+	s := state{le64dec(n[0:]), le64dec(n[8:]), 0, 0, 0}
+	s.rounds(6)
+	h1, h2 := s[0], s[1]|1
+	m := a.opts.FilterBits
+	present := true
+	for i := 0; i < a.opts.FilterHashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if a.filter[word]&mask == 0 {
+			present = false
+			a.filter[word] |= mask
+		}
+	}
+	return present
+}
@@ -0,0 +1,85 @@
+package ascon
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"log"
+	"strings"
+	"testing"
+)
+
+var _ cipher.AEAD = (*NonceCheckAEAD128)(nil)
+
+func TestNonceCheck(t *testing.T) {
+	for _, filterBits := range []uint64{0, 1 << 16} {
+		var reused [][]byte
+		a, err := NewNonceCheckAEAD128(make([]byte, KeySize), NonceCheckOptions{
+			OnReuse:    func(n []byte) { reused = append(reused, append([]byte{}, n...)) },
+			FilterBits: filterBits,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, _ := NewAEAD128(make([]byte, KeySize))
+		nonce := make([]byte, NonceSize)
+		for i := 0; i < 100; i++ {
+			nonce[NonceSize-1] = byte(i)
+			c, err := a.TrySeal(nil, nonce, []byte("msg"), nil)
+			if err != nil {
+				t.Fatalf("filterBits=%d: nonce %d: unexpected error: %v", filterBits, i, err)
+			}
+			if want := plain.Seal(nil, nonce, []byte("msg"), nil); !bytes.Equal(c, want) {
+				t.Errorf("filterBits=%d: ciphertext differs from AEAD128", filterBits)
+			}
+		}
+		if a.Count() != 100 {
+			t.Errorf("filterBits=%d: Count() = %d, want 100", filterBits, a.Count())
+		}
+
+		nonce[NonceSize-1] = 42
+		c, err := a.TrySeal([]byte("dst"), nonce, []byte("msg"), nil)
+		if err != ErrNonceReuse {
+			t.Errorf("filterBits=%d: got error %v, want %v", filterBits, err, ErrNonceReuse)
+		}
+		if string(c) != "dst" {
+			t.Errorf("filterBits=%d: dst was modified", filterBits)
+		}
+		if len(reused) != 1 || !bytes.Equal(reused[0], nonce) {
+			t.Errorf("filterBits=%d: OnReuse called with %X, want %X", filterBits, reused, nonce)
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != ErrNonceReuse {
+					t.Errorf("filterBits=%d: Seal got panic %v, want %v", filterBits, r, ErrNonceReuse)
+				}
+			}()
+			a.Seal(nil, nonce, nil, nil)
+		}()
+
+		// A new key forgets the old nonces
+		a.SetKey(make([]byte, KeySize))
+		if _, err := a.TrySeal(nil, nonce, nil, nil); err != nil {
+			t.Errorf("filterBits=%d: nonce rejected after SetKey: %v", filterBits, err)
+		}
+	}
+}
+
+func TestNonceCheckLog(t *testing.T) {
+	var buf bytes.Buffer
+	a, _ := NewNonceCheckAEAD128(make([]byte, KeySize), NonceCheckOptions{
+		Mode:   NonceReuseLog,
+		Logger: log.New(&buf, "", 0),
+	})
+	nonce := make([]byte, NonceSize)
+	c1 := a.Seal(nil, nonce, []byte("msg"), nil)
+	c2, err := a.TrySeal(nil, nonce, []byte("msg"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error in log mode: %v", err)
+	}
+	if !bytes.Equal(c1, c2) {
+		t.Error("log mode did not seal the message")
+	}
+	if want := "ascon: nonce 00000000000000000000000000000000 reused"; !strings.Contains(buf.String(), want) {
+		t.Errorf("got log %q, want %q", buf.String(), want)
+	}
+}
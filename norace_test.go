@@ -0,0 +1,5 @@
+// +build !race
+
+package ascon
+
+const raceEnabled = false
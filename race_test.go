@@ -0,0 +1,7 @@
+// +build race
+
+package ascon
+
+// raceEnabled reports whether the race detector is on.
+// It makes some functions allocate, so allocation tests are skipped.
+const raceEnabled = true
@@ -0,0 +1,240 @@
+package ascon
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownAlgorithm is returned when an algorithm name is not in the registry,
+// or names a different kind of algorithm than was asked for.
+var ErrUnknownAlgorithm = errors.New("ascon: unknown algorithm")
+
+// AlgorithmKind says what interface an algorithm implements.
+type AlgorithmKind int
+
+const (
+	KindAEAD AlgorithmKind = iota + 1 // cipher.AEAD
+	KindHash                          // hash.Hash
+	KindXOF                           // XOF
+)
+
+func (k AlgorithmKind) String() string {
+	switch k {
+	case KindAEAD:
+		return "AEAD"
+	case KindHash:
+		return "hash"
+	case KindXOF:
+		return "XOF"
+	}
+	return fmt.Sprintf("AlgorithmKind(%d)", int(k))
+}
+
+// XOF is the interface implemented by the extendable-output functions
+// in the registry, such as Xof128 and Cxof128.
+type XOF interface {
+	io.Writer
+	io.Reader
+	Reset()
+}
+
+// Algorithm describes an algorithm in the registry.
+type Algorithm struct {
+	// Name is the canonical name, such as "Ascon-AEAD128".
+	Name string
+	Kind AlgorithmKind
+
+	// Sizes in bytes. KeySize, NonceSize and TagSize are only set for AEADs,
+	// and Size is only set for hashes.
+	KeySize   int
+	NonceSize int
+	TagSize   int
+	Size      int
+
+	// Rate is the number of bytes absorbed per permutation call.
+	Rate int
+	// RoundsA is the number of rounds of the permutation used for
+	// initialization and finalization, and RoundsB the number used
+	// between blocks of data.
+	RoundsA int
+	RoundsB int
+
+	// Customizable reports whether the XOF accepts a customization string.
+	Customizable bool
+
+	newAEAD func(key []byte) (cipher.AEAD, error)
+	newHash func() hash.Hash
+	newXOF  func(customization string) (XOF, error)
+}
+
+var registry = map[string]*Algorithm{}
+
+// register adds an algorithm to the registry.
+// Names are matched without regard to case.
+func register(a *Algorithm) {
+	key := strings.ToLower(a.Name)
+	if _, dup := registry[key]; dup {
+		panic("ascon: duplicate algorithm " + a.Name)
+	}
+	registry[key] = a
+}
+
+func init() {
+	register(&Algorithm{
+		Name:      "Ascon-AEAD128",
+		Kind:      KindAEAD,
+		KeySize:   KeySize,
+		NonceSize: NonceSize,
+		TagSize:   TagSize,
+		Rate:      16,
+		RoundsA:   12,
+		RoundsB:   8,
+		newAEAD: func(key []byte) (cipher.AEAD, error) {
+			a, err := NewAEAD128(key)
+			if err != nil {
+				return nil, err
+			}
+			return a, nil
+		},
+	})
+	register(&Algorithm{
+		Name:    "Ascon-Hash256",
+		Kind:    KindHash,
+		Size:    HashSize,
+		Rate:    BlockSize,
+		RoundsA: 12,
+		RoundsB: 12,
+		newHash: func() hash.Hash { return NewHash256() },
+	})
+	register(&Algorithm{
+		Name:    "Ascon-XOF128",
+		Kind:    KindXOF,
+		Rate:    BlockSize,
+		RoundsA: 12,
+		RoundsB: 12,
+		newXOF: func(customization string) (XOF, error) {
+			return NewXof128(), nil
+		},
+	})
+	register(&Algorithm{
+		Name:         "Ascon-CXOF128",
+		Kind:         KindXOF,
+		Rate:         BlockSize,
+		RoundsA:      12,
+		RoundsB:      12,
+		Customizable: true,
+		newXOF: func(customization string) (XOF, error) {
+			x, err := NewCxof128(customization)
+			if err != nil {
+				return nil, err
+			}
+			return x, nil
+		},
+	})
+
+	// Ascon v1.2
+	register(&Algorithm{
+		Name:    "Ascon-Hash-v1.2",
+		Kind:    KindHash,
+		Size:    HashSize,
+		Rate:    BlockSize,
+		RoundsA: 12,
+		RoundsB: 12,
+		newHash: func() hash.Hash { return NewHashV12() },
+	})
+	register(&Algorithm{
+		Name:    "Ascon-Xof-v1.2",
+		Kind:    KindXOF,
+		Rate:    BlockSize,
+		RoundsA: 12,
+		RoundsB: 12,
+		newXOF: func(customization string) (XOF, error) {
+			return NewXofV12(), nil
+		},
+	})
+	register(&Algorithm{
+		Name:    "Ascon-Hasha-v1.2",
+		Kind:    KindHash,
+		Size:    HashSize,
+		Rate:    BlockSize,
+		RoundsA: 12,
+		RoundsB: 8,
+		newHash: func() hash.Hash { return NewHasha() },
+	})
+	register(&Algorithm{
+		Name:    "Ascon-Xofa-v1.2",
+		Kind:    KindXOF,
+		Rate:    BlockSize,
+		RoundsA: 12,
+		RoundsB: 8,
+		newXOF: func(customization string) (XOF, error) {
+			return NewXofa(), nil
+		},
+	})
+}
+
+// Algorithms returns the registered algorithms, sorted by name.
+func Algorithms() []Algorithm {
+	list := make([]Algorithm, 0, len(registry))
+	for _, a := range registry {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupAlgorithm returns the algorithm with the given name, ignoring case.
+func LookupAlgorithm(name string) (Algorithm, bool) {
+	a, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return Algorithm{}, false
+	}
+	return *a, true
+}
+
+func lookupKind(name string, kind AlgorithmKind) (*Algorithm, error) {
+	a, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAlgorithm, name)
+	}
+	if a.Kind != kind {
+		return nil, fmt.Errorf("%w: %s has kind %v, not %v", ErrUnknownAlgorithm, a.Name, a.Kind, kind)
+	}
+	return a, nil
+}
+
+// NewAEAD returns the named AEAD with the given key.
+func NewAEAD(name string, key []byte) (cipher.AEAD, error) {
+	a, err := lookupKind(name, KindAEAD)
+	if err != nil {
+		return nil, err
+	}
+	return a.newAEAD(key)
+}
+
+// NewHash returns the named hash function.
+func NewHash(name string) (hash.Hash, error) {
+	a, err := lookupKind(name, KindHash)
+	if err != nil {
+		return nil, err
+	}
+	return a.newHash(), nil
+}
+
+// NewXOF returns the named XOF.
+// The customization string must be empty unless the XOF is Customizable.
+func NewXOF(name, customization string) (XOF, error) {
+	a, err := lookupKind(name, KindXOF)
+	if err != nil {
+		return nil, err
+	}
+	if customization != "" && !a.Customizable {
+		return nil, fmt.Errorf("ascon: %s does not take a customization string", a.Name)
+	}
+	return a.newXOF(customization)
+}
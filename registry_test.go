@@ -0,0 +1,86 @@
+package ascon
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	want := []string{
+		"Ascon-AEAD128",
+		"Ascon-CXOF128",
+		"Ascon-Hash-v1.2",
+		"Ascon-Hash256",
+		"Ascon-Hasha-v1.2",
+		"Ascon-XOF128",
+		"Ascon-Xof-v1.2",
+		"Ascon-Xofa-v1.2",
+	}
+	var names []string
+	for _, a := range Algorithms() {
+		names = append(names, a.Name)
+		if a.Rate == 0 || a.RoundsA == 0 || a.RoundsB == 0 {
+			t.Errorf("%s: missing parameters: %+v", a.Name, a)
+		}
+	}
+	for _, name := range want {
+		if _, ok := LookupAlgorithm(name); !ok {
+			t.Errorf("%s is not registered", name)
+		}
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Algorithms() = %q, want %q", names, want)
+	}
+
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(NonceSize, 0x10)
+	aead, err := NewAEAD("ascon-aead128", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := NewAEAD128(key)
+	if got, want := aead.Seal(nil, nonce, []byte("msg"), nil), a.Seal(nil, nonce, []byte("msg"), nil); !bytes.Equal(got, want) {
+		t.Errorf("NewAEAD: got %X, want %X", got, want)
+	}
+	if aead, err := NewAEAD("Ascon-AEAD128", key[:8]); aead != nil || !errors.Is(err, ErrKeySize) {
+		t.Errorf("NewAEAD with a short key = %v, %v", aead, err)
+	}
+
+	h, err := NewHash("Ascon-Hash256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write([]byte("msg"))
+	h2 := NewHash256()
+	h2.Write([]byte("msg"))
+	if got, want := h.Sum(nil), h2.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("NewHash: got %X, want %X", got, want)
+	}
+
+	x, err := NewXOF("Ascon-CXOF128", "custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x2, _ := NewCxof128("custom")
+	out, out2 := make([]byte, 32), make([]byte, 32)
+	x.Read(out)
+	x2.Read(out2)
+	if !bytes.Equal(out, out2) {
+		t.Errorf("NewXOF: got %X, want %X", out, out2)
+	}
+	if _, err := NewXOF("Ascon-XOF128", "custom"); err == nil {
+		t.Error("NewXOF accepted a customization string for Ascon-XOF128")
+	}
+	if x, err := NewXOF("Ascon-CXOF128", string(make([]byte, 257))); x != nil || !errors.Is(err, ErrCustomizationSize) {
+		t.Errorf("NewXOF with a long customization string = %v, %v", x, err)
+	}
+
+	if _, err := NewHash("Ascon-AEAD128"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("NewHash(Ascon-AEAD128): got %v, want ErrUnknownAlgorithm", err)
+	}
+	if _, err := NewAEAD("AES-GCM", key); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("NewAEAD(AES-GCM): got %v, want ErrUnknownAlgorithm", err)
+	}
+}
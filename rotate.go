@@ -0,0 +1,116 @@
+package ascon
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RotatingAEAD128 is an Ascon-AEAD128 whose key can be replaced
+// while other goroutines are using it.
+// It implements the crypto/cipher.AEAD interface.
+//
+// Seal and Open don't take any locks.
+// Each call loads the keys once, so it uses the same key from start to finish,
+// even if SetKey is called in the middle of it.
+//
+// To avoid failing messages which were sealed just before a rotation,
+// Open also tries the previous key if the current one fails.
+// Old keys are left for the garbage collector rather than destroyed,
+// since calls which are still in progress may be using them.
+//
+// All methods are safe for concurrent use.
+type RotatingAEAD128 struct {
+	keys atomic.Value // *rotatingKeys
+	mu   sync.Mutex   // serializes SetKey
+}
+
+type rotatingKeys struct {
+	current  *AEAD128
+	previous *AEAD128 // may be nil
+}
+
+func NewRotatingAEAD128(key []byte) (*RotatingAEAD128, error) {
+	a, err := NewAEAD128(key)
+	if err != nil {
+		return nil, err
+	}
+	r := new(RotatingAEAD128)
+	r.keys.Store(&rotatingKeys{current: a})
+	return r, nil
+}
+
+// SetKey replaces the key.
+// The old key remains available to Open until the next call to SetKey.
+// It returns ErrKeySize if the key is not KeySize bytes long.
+func (r *RotatingAEAD128) SetKey(key []byte) error {
+	a, err := NewAEAD128(key)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load()
+	r.keys.Store(&rotatingKeys{current: a, previous: old.current})
+	return nil
+}
+
+// DropPrevious forgets the previous key,
+// so that Open only accepts messages sealed with the current one.
+func (r *RotatingAEAD128) DropPrevious() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load()
+	r.keys.Store(&rotatingKeys{current: old.current})
+}
+
+func (r *RotatingAEAD128) load() *rotatingKeys {
+	return r.keys.Load().(*rotatingKeys)
+}
+
+func (*RotatingAEAD128) NonceSize() int { return NonceSize }
+func (*RotatingAEAD128) Overhead() int  { return TagSize }
+
+// Seal is like AEAD128.Seal, using the current key.
+func (r *RotatingAEAD128) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	return r.load().current.Seal(dst, nonce, plaintext, additionalData)
+}
+
+// TrySeal is like AEAD128.TrySeal, using the current key.
+func (r *RotatingAEAD128) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	return r.load().current.TrySeal(dst, nonce, plaintext, additionalData)
+}
+
+// Open is like AEAD128.Open.
+// It tries the current key, then the previous key.
+//
+// While there is a previous key, the plaintext is decrypted into
+// a temporary buffer and only copied to dst once it is authentic,
+// since a failed attempt would overwrite the ciphertext
+// if dst and ciphertext overlap.
+func (r *RotatingAEAD128) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	keys := r.load()
+	if keys.previous == nil || len(ciphertext) < TagSize {
+		return keys.current.Open(dst, nonce, ciphertext, additionalData)
+	}
+	buf := make([]byte, 0, len(ciphertext)-TagSize)
+	p, err := keys.current.Open(buf, nonce, ciphertext, additionalData)
+	if err == ErrOpen {
+		p, err = keys.previous.Open(buf, nonce, ciphertext, additionalData)
+	}
+	if err == nil {
+		dst = append(dst, p...)
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	return dst, err
+}
+
+// TryOpen is like Open, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+func (r *RotatingAEAD128) TryOpen(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		return dst, ErrNonceSize
+	}
+	return r.Open(dst, nonce, ciphertext, additionalData)
+}
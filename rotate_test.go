@@ -0,0 +1,120 @@
+package ascon
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"sync"
+	"testing"
+)
+
+var _ cipher.AEAD = (*RotatingAEAD128)(nil)
+
+func TestRotatingAEAD(t *testing.T) {
+	k0, k1, k2 := mkPattern(KeySize, 0x00), mkPattern(KeySize, 0x10), mkPattern(KeySize, 0x20)
+	nonce := mkPattern(NonceSize, 0x30)
+	msg := []byte("message")
+	r, err := NewRotatingAEAD128(k0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a0, _ := NewAEAD128(k0)
+	c0 := r.Seal(nil, nonce, msg, nil)
+	if want := a0.Seal(nil, nonce, msg, nil); !bytes.Equal(c0, want) {
+		t.Errorf("Seal = %X, want %X", c0, want)
+	}
+
+	if err := r.SetKey(k1); err != nil {
+		t.Fatal(err)
+	}
+	c1 := r.Seal(nil, nonce, msg, nil)
+	if bytes.Equal(c0, c1) {
+		t.Error("SetKey did not change the key")
+	}
+	for _, c := range [][]byte{c0, c1} {
+		if p, err := r.Open(nil, nonce, c, nil); err != nil || !bytes.Equal(p, msg) {
+			t.Errorf("Open(%X) = %q, %v", c, p, err)
+		}
+		// in place
+		buf := append([]byte(nil), c...)
+		if p, err := r.Open(buf[:0], nonce, buf, nil); err != nil || !bytes.Equal(p, msg) {
+			t.Errorf("Open(%X) in place = %q, %v", c, p, err)
+		}
+	}
+	// a failed Open leaves the ciphertext alone
+	buf := append([]byte(nil), c0...)
+	buf[0] ^= 1
+	if _, err := r.Open(buf[:0], nonce, buf, nil); err != ErrOpen {
+		t.Errorf("Open of a modified ciphertext in place: got %v, want ErrOpen", err)
+	}
+	if buf[0] ^= 1; !bytes.Equal(buf, c0) {
+		t.Errorf("failed Open in place changed the ciphertext to %X", buf)
+	}
+
+	// k0 is forgotten after the next rotation
+	r.SetKey(k2)
+	if _, err := r.Open(nil, nonce, c0, nil); err == nil {
+		t.Error("Open succeeded with a key two rotations old")
+	}
+	if _, err := r.Open(nil, nonce, c1, nil); err != nil {
+		t.Errorf("Open with the previous key failed: %v", err)
+	}
+	r.DropPrevious()
+	if _, err := r.Open(nil, nonce, c1, nil); err == nil {
+		t.Error("Open succeeded with the previous key after DropPrevious")
+	}
+
+	if err := r.SetKey(k0[:8]); err != ErrKeySize {
+		t.Errorf("SetKey with a short key: got %v, want ErrKeySize", err)
+	}
+	if _, err := r.TryOpen(nil, nonce[:8], c1, nil); err != ErrNonceSize {
+		t.Errorf("TryOpen with a short nonce: got %v, want ErrNonceSize", err)
+	}
+}
+
+// TestRotatingAEADConcurrent is most useful with the race detector.
+func TestRotatingAEADConcurrent(t *testing.T) {
+	const numKeys = 50
+	var keys [numKeys]*AEAD128
+	for i := range keys {
+		keys[i], _ = NewAEAD128(mkPattern(KeySize, byte(i)))
+	}
+	r, _ := NewRotatingAEAD128(mkPattern(KeySize, 0))
+	nonce := mkPattern(NonceSize, 0x80)
+	msg := mkPattern(100, 0x40)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				c := r.Seal(nil, nonce, msg, nil)
+				// the whole message must have been sealed with one key
+				ok := false
+				for _, a := range keys {
+					if _, err := a.Open(nil, nonce, c, nil); err == nil {
+						ok = true
+						break
+					}
+				}
+				if !ok {
+					t.Error("Seal used an inconsistent key")
+					return
+				}
+				// Open may fail if the key was rotated twice in between
+				r.Open(nil, nonce, c, nil)
+			}
+		}()
+	}
+	for i := 1; i < numKeys; i++ {
+		r.SetKey(mkPattern(KeySize, byte(i)))
+	}
+	close(done)
+	wg.Wait()
+}
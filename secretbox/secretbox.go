@@ -0,0 +1,77 @@
+// The secretbox package encrypts and authenticates small messages
+// with Ascon-AEAD128 and a random nonce, in a single call.
+//
+// A box has the layout
+//
+//	version    byte (1)
+//	nonce      [ascon.NonceSize]byte
+//	ciphertext [len(plaintext)]byte
+//	tag        [ascon.TagSize]byte
+//
+// The plaintext is sealed with the key and nonce,
+// and the version byte as additional data.
+//
+// Nonces are random, so no more than about 2^48 messages
+// should be sealed with the same key.
+package secretbox
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/magical/go-ascon"
+)
+
+const (
+	// Version is the version byte at the start of every box.
+	Version = 1
+
+	// Overhead is the number of bytes a box adds to its plaintext.
+	Overhead = 1 + ascon.NonceSize + ascon.TagSize
+)
+
+var errVersion = errors.New("secretbox: unknown version")
+
+// Seal encrypts and authenticates plaintext with key,
+// which must be ascon.KeySize bytes long, and returns the box.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	return seal(key, plaintext, rand.Reader)
+}
+
+func seal(key, plaintext []byte, rand io.Reader) ([]byte, error) {
+	a, err := ascon.NewAEAD128(key)
+	if err != nil {
+		return nil, err
+	}
+	box := make([]byte, 1+ascon.NonceSize, len(plaintext)+Overhead)
+	box[0] = Version
+	nonce := box[1:]
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, err
+	}
+	return a.Seal(box, nonce, plaintext, box[:1]), nil
+}
+
+// Open authenticates and decrypts a box produced by Seal
+// and returns the plaintext.
+// If the box is not authentic, it returns a nil plaintext and an error.
+func Open(key, box []byte) ([]byte, error) {
+	a, err := ascon.NewAEAD128(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(box) < Overhead {
+		return nil, ascon.ErrOpen
+	}
+	if box[0] != Version {
+		return nil, errVersion
+	}
+	nonce := box[1 : 1+ascon.NonceSize]
+	p, err := a.Open(nil, nonce, box[1+ascon.NonceSize:], box[:1])
+	if err != nil {
+		// don't return unauthenticated plaintext
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,88 @@
+package secretbox
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/magical/go-ascon"
+)
+
+func pattern(n int, base byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = base + byte(i)
+	}
+	return b
+}
+
+func TestGolden(t *testing.T) {
+	key := pattern(ascon.KeySize, 0x00)
+	nonce := pattern(ascon.NonceSize, 0x10)
+	tests := []struct {
+		plaintext []byte
+		want      string
+	}{
+		{nil, "01101112131415161718191A1B1C1D1E1FAFB60BB6945B276BC0385EA01496D1B5"},
+		{pattern(1, 0x20), "01101112131415161718191A1B1C1D1E1FC34573BB42B393A96AF2D08B160D440BB4"},
+		{pattern(20, 0x20), "01101112131415161718191A1B1C1D1E1FC392A152A03C110B0FA9057BABF4CD39F449B5B44D2A22D68671049D07CFFD095C168931"},
+	}
+	for _, tt := range tests {
+		box, err := seal(key, tt.plaintext, bytes.NewReader(nonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%X", box); got != tt.want {
+			t.Errorf("len=%d: got %s, want %s", len(tt.plaintext), got, tt.want)
+		}
+		// the layout is version || nonce || ciphertext || tag
+		if box[0] != Version || !bytes.Equal(box[1:1+ascon.NonceSize], nonce) || len(box) != len(tt.plaintext)+Overhead {
+			t.Errorf("len=%d: bad layout %X", len(tt.plaintext), box)
+		}
+		a, _ := ascon.NewAEAD128(key)
+		if c := a.Seal(nil, nonce, tt.plaintext, []byte{Version}); !bytes.Equal(box[1+ascon.NonceSize:], c) {
+			t.Errorf("len=%d: ciphertext does not match AEAD128", len(tt.plaintext))
+		}
+		p, err := Open(key, box)
+		if err != nil {
+			t.Errorf("len=%d: Open failed: %v", len(tt.plaintext), err)
+		} else if !bytes.Equal(p, tt.plaintext) {
+			t.Errorf("len=%d: Open returned %X, want %X", len(tt.plaintext), p, tt.plaintext)
+		}
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key := pattern(ascon.KeySize, 0x00)
+	msg := []byte("attack at dawn")
+	box1, err := Seal(key, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	box2, _ := Seal(key, msg)
+	if bytes.Equal(box1, box2) {
+		t.Error("two boxes of the same message are equal")
+	}
+	if p, err := Open(key, box1); err != nil || !bytes.Equal(p, msg) {
+		t.Errorf("Open = %q, %v", p, err)
+	}
+
+	for i := range box1 {
+		box1[i] ^= 1
+		if p, err := Open(key, box1); err == nil {
+			t.Errorf("Open succeeded with byte %d modified", i)
+		} else if p != nil {
+			t.Errorf("Open returned plaintext %X with byte %d modified", p, i)
+		}
+		box1[i] ^= 1
+	}
+	if _, err := Open(key, box1[:Overhead-1]); err == nil {
+		t.Error("Open succeeded with a short box")
+	}
+	if _, err := Open(pattern(ascon.KeySize, 0x01), box1); err == nil {
+		t.Error("Open succeeded with the wrong key")
+	}
+	if _, err := Seal(key[:8], msg); err == nil {
+		t.Error("Seal succeeded with a short key")
+	}
+}
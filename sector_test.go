@@ -0,0 +1,92 @@
+package ascon
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestSectorCipher(t *testing.T) {
+	c, err := NewSectorCipher(mkPattern(KeySize, 0x00))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		n      int
+		sector uint64
+		want   string
+	}{
+		{32, 0, "4AEDA60C859102B8351C9C4C073F6BB3871B1F0ED8632CCEF334D45C059DE110"},
+		{32, 1, "B0A21FAC52FB88A8F07EF7B0F97199A47622AEA427360D68285A117DCE567E3F"},
+		{33, 0, "7BDBE7A4CAE01639D39083C43BADF66D10104F16AED5805BC1F41DDBF170BC498A"},
+	}
+	for _, tt := range tests {
+		src := mkPattern(tt.n, 0x40)
+		dst := make([]byte, tt.n)
+		if err := c.EncryptSector(dst, src, tt.sector); err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%X", dst); got != tt.want {
+			t.Errorf("len=%d sector=%d: got %s, want %s", tt.n, tt.sector, got, tt.want)
+		}
+	}
+}
+
+func TestSectorCipherInverse(t *testing.T) {
+	c, _ := NewSectorCipher(mkPattern(KeySize, 0x00))
+	for _, n := range []int{MinSectorSize, 33, 47, 48, 64, 100, 512, 4096} {
+		src := mkPattern(n, 0x40)
+		enc := make([]byte, n)
+		if err := c.EncryptSector(enc, src, 7); err != nil {
+			t.Fatal(err)
+		}
+		dec := make([]byte, n)
+		if err := c.DecryptSector(dec, enc, 7); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(dec, src) {
+			t.Errorf("len=%d: Decrypt(Encrypt(x)) != x", n)
+		}
+
+		// in place
+		buf := append([]byte(nil), src...)
+		c.EncryptSector(buf, buf, 7)
+		if !bytes.Equal(buf, enc) {
+			t.Errorf("len=%d: in-place Encrypt differs", n)
+		}
+		c.DecryptSector(buf, buf, 7)
+		if !bytes.Equal(buf, src) {
+			t.Errorf("len=%d: in-place Decrypt differs", n)
+		}
+
+		// a different sector number gives an unrelated ciphertext
+		other := make([]byte, n)
+		c.EncryptSector(other, src, 8)
+		if bytes.Equal(other[:8], enc[:8]) || bytes.Equal(other[n-8:], enc[n-8:]) {
+			t.Errorf("len=%d: sectors 7 and 8 encrypt similarly", n)
+		}
+
+		// changing the last byte changes the first, and vice versa
+		for _, i := range []int{0, n - 1} {
+			src[i] ^= 1
+			c.EncryptSector(other, src, 7)
+			src[i] ^= 1
+			if bytes.Equal(other[:8], enc[:8]) || bytes.Equal(other[n-8:], enc[n-8:]) {
+				t.Errorf("len=%d: changing byte %d does not affect the whole sector", n, i)
+			}
+		}
+	}
+}
+
+func TestSectorCipherErrors(t *testing.T) {
+	c, _ := NewSectorCipher(mkPattern(KeySize, 0x00))
+	if err := c.Encrypt(make([]byte, MinSectorSize-1), make([]byte, MinSectorSize-1), nil); err == nil {
+		t.Errorf("encrypted a %d-byte sector", MinSectorSize-1)
+	}
+	if err := c.Decrypt(make([]byte, 32), make([]byte, 33), nil); err == nil {
+		t.Error("decrypted into a buffer of the wrong size")
+	}
+	if _, err := NewSectorCipher(make([]byte, 8)); err != ErrKeySize {
+		t.Errorf("NewSectorCipher with a short key: got %v, want ErrKeySize", err)
+	}
+}
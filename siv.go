@@ -0,0 +1,159 @@
+package ascon
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// SIV128 is a deterministic, nonce-misuse-resistant AEAD built from Ascon.
+// It implements the crypto/cipher.AEAD interface.
+//
+// Seal first computes a synthetic IV by running Ascon-CXOF128,
+// keyed with a subkey derived from the key,
+// over the nonce, the additional data and the plaintext.
+// The plaintext is then encrypted with the Ascon-AEAD128 duplex under a second subkey,
+// using the synthetic IV as the nonce.
+// The synthetic IV doubles as the authentication tag
+// and is appended to the ciphertext.
+//
+// The nonce is optional: it may be either empty or NonceSize bytes long.
+// Without a nonce, sealing the same plaintext and additional data twice
+// produces the same ciphertext, but reveals nothing else;
+// with a unique nonce, SIV128 is as private as AEAD128.
+type SIV128 struct {
+	mac Cxof128 // keyed with the key; never finalized
+	enc AEAD128 // keyed with the encryption subkey; used only for its key
+}
+
+const (
+	sivMacCustomization = "Ascon-SIV128 MAC"
+	sivEncCustomization = "Ascon-SIV128 ENC"
+)
+
+func NewSIV128(key []byte) (*SIV128, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	a := new(SIV128)
+	a.mac = newKeyedCxof(sivMacCustomization, key)
+	var enc [KeySize]byte
+	kdf := newKeyedCxof(sivEncCustomization, key)
+	kdf.digest.read(enc[:])
+	kdf.digest.wipe()
+	a.enc.SetKey(enc[:])
+	for i := range enc {
+		enc[i] = 0
+	}
+	return a, nil
+}
+
+// NonceSize returns the size of the nonce.
+// Seal and Open also accept an empty nonce.
+func (*SIV128) NonceSize() int { return NonceSize }
+func (*SIV128) Overhead() int  { return TagSize }
+
+// Seal encrypts and authenticates a plaintext
+// and appends ciphertext to dst, returning the appended slice.
+func (a *SIV128) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize && len(nonce) != 0 {
+		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
+	}
+
+	var iv [TagSize]byte
+	a.syntheticIV(&iv, nonce, plaintext, additionalData)
+
+	// allocate space
+	dstLen := len(dst)
+	dst = extend(dst, len(plaintext)+TagSize)
+
+	// Encrypt. May scribble over the space reserved for the tag
+	var s state
+	a.initDuplex(&s, iv[:])
+	s.encrypt(plaintext, 0, dst[dstLen:], 8)
+	s.wipe()
+
+	// Append tag
+	copy(dst[dstLen+len(plaintext):], iv[:])
+	return dst
+}
+
+// TrySeal is like Seal, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+// In that case dst is returned unchanged.
+func (a *SIV128) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize && len(nonce) != 0 {
+		return dst, ErrNonceSize
+	}
+	return a.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// TryOpen is like Open, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+func (a *SIV128) TryOpen(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize && len(nonce) != 0 {
+		return dst, ErrNonceSize
+	}
+	return a.Open(dst, nonce, ciphertext, additionalData)
+}
+
+func (a *SIV128) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize && len(nonce) != 0 {
+		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
+	}
+
+	if len(ciphertext) < TagSize {
+		return dst, ErrOpen
+	}
+	plaintextSize := len(ciphertext) - TagSize
+	iv := ciphertext[plaintextSize:]
+	ciphertext = ciphertext[:plaintextSize]
+
+	dstLen := len(dst)
+	dst = extend(dst, plaintextSize)
+	plaintext := dst[dstLen:]
+
+	// Decrypt
+	var s state
+	a.initDuplex(&s, iv)
+	s.decrypt(ciphertext, 0, plaintext, 8)
+	s.wipe()
+
+	// Recompute the synthetic IV and check it in constant time
+	var expectedIV [TagSize]byte
+	a.syntheticIV(&expectedIV, nonce, plaintext, additionalData)
+	if subtle.ConstantTimeCompare(expectedIV[:], iv) != 1 {
+		// don't release unauthenticated plaintext
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return dst, ErrOpen
+	}
+
+	return dst, nil
+}
+
+// syntheticIV computes the MAC of the nonce, additional data and plaintext.
+// The nonce and additional data are prefixed with their lengths
+// so that the encoding is unambiguous.
+func (a *SIV128) syntheticIV(iv *[TagSize]byte, nonce, plaintext, additionalData []byte) {
+	x := a.mac // copy
+	x.digest.writeLength(len(nonce))
+	x.digest.write(nonce)
+	x.digest.writeLength(len(additionalData))
+	x.digest.write(additionalData)
+	x.digest.write(plaintext)
+	x.digest.read(iv[:])
+	x.digest.wipe()
+}
+
+// initDuplex initializes s the same way as Ascon-AEAD128
+// with the encryption subkey, the synthetic IV as the nonce,
+// and no additional data.
+func (a *SIV128) initDuplex(s *state, iv []byte) {
+	const A, B uint = 12, 8
+	s.initAEADle(a.enc.k0, a.enc.k1, 128, uint8(A), uint8(B), iv)
+	s[3] ^= a.enc.k0
+	s[4] ^= a.enc.k1
+	// domain-separation constant
+	s[4] ^= 0x80 << 56
+}
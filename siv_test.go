@@ -0,0 +1,92 @@
+package ascon
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+)
+
+var _ cipher.AEAD = (*SIV128)(nil)
+
+func TestSIV(t *testing.T) {
+	var (
+		key   = unhex("000102030405060708090A0B0C0D0E0F")
+		nonce = unhex("101112131415161718191A1B1C1D1E1F")
+		text  = unhex("202122232425262728292A2B2C2D2E2F3031")
+		ad    = unhex("303132333435")
+	)
+	tests := []struct {
+		nonce []byte
+		want  string
+	}{
+		{nil, "37F4A15304EA73FF48245A9C89F1CB31CC0BDFA218CAFF1EC34205BB14AD9617D458"},
+		{nonce, "1FE61DF64F1BC4D4BFEC6980D6C5C2976F190E95B76956405533B66214895F6A2539"},
+	}
+	a, err := NewSIV128(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		c := a.Seal(nil, tt.nonce, text, ad)
+		got := fmt.Sprintf("%X", c)
+		if got != tt.want {
+			t.Errorf("nonce=%X: got %s, want %s", tt.nonce, got, tt.want)
+		}
+		p, err := a.Open(nil, tt.nonce, c, ad)
+		if err != nil {
+			t.Errorf("nonce=%X: Open failed: %v", tt.nonce, err)
+		} else if !bytes.Equal(p, text) {
+			t.Errorf("nonce=%X: Open returned %X, want %X", tt.nonce, p, text)
+		}
+	}
+}
+
+func TestSIVRoundTrip(t *testing.T) {
+	a, _ := NewSIV128(make([]byte, KeySize))
+	for n := 0; n <= 40; n++ {
+		msg := make([]byte, n)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		ad := msg[:n/2]
+		c := a.Seal(nil, nil, msg, ad)
+		if len(c) != n+TagSize {
+			t.Errorf("len=%d: ciphertext has length %d, want %d", n, len(c), n+TagSize)
+		}
+		if c2 := a.Seal(nil, nil, msg, ad); !bytes.Equal(c, c2) {
+			t.Errorf("len=%d: Seal is not deterministic", n)
+		}
+		p, err := a.Open(nil, nil, c, ad)
+		if err != nil {
+			t.Errorf("len=%d: decryption failed: %v", n, err)
+		} else if !bytes.Equal(p, msg) {
+			t.Errorf("len=%d: got %X, want %X", n, p, msg)
+		}
+
+		c[n%len(c)] ^= 1
+		if p, err := a.Open(nil, nil, c, ad); err == nil {
+			t.Errorf("len=%d: decryption succeeded unexpectedly", n)
+		} else if !bytes.Equal(p, make([]byte, n)) {
+			t.Errorf("len=%d: failed Open released plaintext %X", n, p)
+		}
+	}
+}
+
+func TestSIVNonce(t *testing.T) {
+	a, _ := NewSIV128(make([]byte, KeySize))
+	msg := []byte("attack at dawn")
+	c0 := a.Seal(nil, nil, msg, nil)
+	c1 := a.Seal(nil, make([]byte, NonceSize), msg, nil)
+	if bytes.Equal(c0, c1) {
+		t.Error("empty nonce and zero nonce produced the same ciphertext")
+	}
+	if _, err := a.Open(nil, make([]byte, NonceSize), c0, nil); err == nil {
+		t.Error("Open succeeded with the wrong nonce")
+	}
+}
+
+func TestSIVInPlace(t *testing.T) {
+	a, _ := NewSIV128(mkPattern(KeySize, 0x00))
+	testAEADInPlace(t, a)
+}
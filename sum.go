@@ -0,0 +1,70 @@
+package ascon
+
+// One-shot functions for hashing and encrypting a single message.
+// They keep all of their state on the stack,
+// so they don't allocate unless they have to grow dst.
+
+// Sum256 returns the Ascon-Hash256 digest of data.
+func Sum256(data []byte) [HashSize]byte {
+	var d, d0 digest
+	var out [HashSize]byte
+	d.reset()
+	d.write(data)
+	d.sumTo(&d0, out[:0])
+	d.wipe()
+	return out
+}
+
+// SumXof128 fills dst with the Ascon-XOF128 output for data.
+func SumXof128(dst, data []byte) {
+	var x Xof128
+	x.Reset()
+	x.digest.write(data)
+	x.digest.read(dst)
+	x.digest.wipe()
+}
+
+// SumCxof128 fills dst with the Ascon-CXOF128 output for data,
+// with the given customization string.
+// It returns ErrCustomizationSize if the string is longer than 256 bytes.
+func SumCxof128(dst []byte, customizationString string, data []byte) error {
+	if len(customizationString) > 256 {
+		return ErrCustomizationSize
+	}
+	// Like Cxof128.init, but without saving the initial state
+	var d digest
+	d.initCxof(len(customizationString) * 8)
+	d.writeString(customizationString)
+	d.finish()
+	d.write(data)
+	d.read(dst)
+	d.wipe()
+	return nil
+}
+
+// SealAEAD128 encrypts and authenticates plaintext with Ascon-AEAD128
+// and appends the ciphertext to dst, like AEAD128.Seal.
+// It returns ErrKeySize or ErrNonceSize if the key or nonce is the wrong length,
+// in which case dst is returned unchanged.
+func SealAEAD128(dst, key, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	var a AEAD128
+	if err := a.TrySetKey(key); err != nil {
+		return dst, err
+	}
+	dst, err := a.TrySeal(dst, nonce, plaintext, additionalData)
+	a.Destroy()
+	return dst, err
+}
+
+// OpenAEAD128 authenticates and decrypts ciphertext with Ascon-AEAD128
+// and appends the plaintext to dst, like AEAD128.Open.
+// It returns ErrKeySize or ErrNonceSize if the key or nonce is the wrong length.
+func OpenAEAD128(dst, key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	var a AEAD128
+	if err := a.TrySetKey(key); err != nil {
+		return dst, err
+	}
+	dst, err := a.TryOpen(dst, nonce, ciphertext, additionalData)
+	a.Destroy()
+	return dst, err
+}
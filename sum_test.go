@@ -0,0 +1,133 @@
+package ascon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8, 15, 16, 100} {
+		msg := mkPattern(n, 0x00)
+
+		h := NewHash256()
+		h.Write(msg)
+		if got, want := Sum256(msg), h.Sum(nil); !bytes.Equal(got[:], want) {
+			t.Errorf("Sum256(%d bytes) = %X, want %X", n, got, want)
+		}
+
+		got := make([]byte, 45)
+		want := make([]byte, 45)
+		x := NewXof128()
+		x.Write(msg)
+		x.Read(want)
+		SumXof128(got, msg)
+		if !bytes.Equal(got, want) {
+			t.Errorf("SumXof128(%d bytes) = %X, want %X", n, got, want)
+		}
+
+		for _, custom := range []string{"", "custom", string(mkPattern(256, 0x00))} {
+			c, _ := NewCxof128(custom)
+			c.Write(msg)
+			c.Read(want)
+			if err := SumCxof128(got, custom, msg); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("SumCxof128(%d byte custom, %d bytes) = %X, want %X", len(custom), n, got, want)
+			}
+		}
+	}
+	if err := SumCxof128(nil, string(make([]byte, 257)), nil); err != ErrCustomizationSize {
+		t.Errorf("SumCxof128 with a long customization string: got %v, want ErrCustomizationSize", err)
+	}
+}
+
+func TestSealAEAD128(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(NonceSize, 0x10)
+	pt := mkPattern(33, 0x20)
+	ad := mkPattern(5, 0x30)
+	a, _ := NewAEAD128(key)
+	want := a.Seal(nil, nonce, pt, ad)
+	ct, err := SealAEAD128(nil, key, nonce, pt, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ct, want) {
+		t.Errorf("SealAEAD128 = %X, want %X", ct, want)
+	}
+	got, err := OpenAEAD128(nil, key, nonce, ct, ad)
+	if err != nil || !bytes.Equal(got, pt) {
+		t.Errorf("OpenAEAD128 = %X, %v; want %X", got, err, pt)
+	}
+	ct[0] ^= 1
+	if _, err := OpenAEAD128(nil, key, nonce, ct, ad); err != ErrOpen {
+		t.Errorf("OpenAEAD128 with a modified ciphertext: got %v, want ErrOpen", err)
+	}
+	if _, err := SealAEAD128(nil, key[:8], nonce, pt, ad); err != ErrKeySize {
+		t.Errorf("SealAEAD128 with a short key: got %v, want ErrKeySize", err)
+	}
+	if _, err := OpenAEAD128(nil, key, nonce[:8], ct, ad); err != ErrNonceSize {
+		t.Errorf("OpenAEAD128 with a short nonce: got %v, want ErrNonceSize", err)
+	}
+}
+
+func TestSumAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("skipping allocation test with the race detector")
+	}
+	msg := mkPattern(100, 0x00)
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(NonceSize, 0x10)
+	out := make([]byte, 64)
+	buf := make([]byte, 0, len(msg)+TagSize)
+	ct, _ := SealAEAD128(nil, key, nonce, msg, nil)
+	custom := string(mkPattern(100, 0x40))
+
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"Sum256", func() { Sum256(msg) }},
+		{"SumXof128", func() { SumXof128(out, msg) }},
+		{"SumCxof128", func() { SumCxof128(out, custom, msg) }},
+		{"SealAEAD128", func() { SealAEAD128(buf[:0], key, nonce, msg, nil) }},
+		{"OpenAEAD128", func() { OpenAEAD128(buf[:0], key, nonce, ct, nil) }},
+	}
+	for _, tt := range tests {
+		if n := testing.AllocsPerRun(10, tt.f); n != 0 {
+			t.Errorf("%s: got %v allocations, want 0", tt.name, n)
+		}
+	}
+}
+
+func BenchmarkSum256(b *testing.B) {
+	msg := make([]byte, 64)
+	b.SetBytes(int64(len(msg)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Sum256(msg)
+	}
+}
+
+func BenchmarkSumXof128(b *testing.B) {
+	msg := make([]byte, 64)
+	out := make([]byte, 32)
+	b.SetBytes(int64(len(msg)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SumXof128(out, msg)
+	}
+}
+
+func BenchmarkSealAEAD128(b *testing.B) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, NonceSize)
+	msg := make([]byte, 64)
+	dst := make([]byte, 0, len(msg)+TagSize)
+	b.SetBytes(int64(len(msg)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		SealAEAD128(dst[:0], key, nonce, msg, nil)
+	}
+}
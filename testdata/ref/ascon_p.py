@@ -0,0 +1,36 @@
+"""The Ascon permutation, written from the specification.
+
+sp800232.py imports it. Together they are a second implementation, used
+to cross-check the bit-length APIs; they are not an official source of
+test vectors.
+"""
+
+MASK = (1 << 64) - 1
+
+
+def rotr(x, n):
+    return ((x >> n) | (x << (64 - n))) & MASK
+
+
+def permute(s, rounds):
+    """Applies the last `rounds` rounds of Ascon-p to the five words s."""
+    for i in range(12 - rounds, 12):
+        # constant addition
+        s[2] ^= ((0xF - i) << 4) | i
+        # substitution layer
+        s[0] ^= s[4]
+        s[4] ^= s[3]
+        s[2] ^= s[1]
+        t = [(s[j] ^ MASK) & s[(j + 1) % 5] for j in range(5)]
+        for j in range(5):
+            s[j] ^= t[(j + 1) % 5]
+        s[1] ^= s[0]
+        s[0] ^= s[4]
+        s[3] ^= s[2]
+        s[2] ^= MASK
+        # linear diffusion layer
+        s[0] ^= rotr(s[0], 19) ^ rotr(s[0], 28)
+        s[1] ^= rotr(s[1], 61) ^ rotr(s[1], 39)
+        s[2] ^= rotr(s[2], 1) ^ rotr(s[2], 6)
+        s[3] ^= rotr(s[3], 10) ^ rotr(s[3], 17)
+        s[4] ^= rotr(s[4], 7) ^ rotr(s[4], 41)
@@ -0,0 +1,171 @@
+"""A bit-level model of Ascon from NIST SP 800-232.
+
+Every input and output is a list of bits, and the algorithms follow the
+pseudocode of the standard directly: bit strings are padded with 1 || 0*,
+split into blocks, and mapped to 64-bit words with bit i of the block as
+bit i of the word. Bytes are only used at the edges, where bit i of a
+string is bit i%8 (counting from the least significant) of byte i//8.
+
+Run it to regenerate sp800232_bits.txt:
+
+	python3 sp800232.py > sp800232_bits.txt
+"""
+
+import sys
+
+from ascon_p import permute
+
+IV_HASH256 = 0x0000080100CC0002
+IV_XOF128 = 0x0000080000CC0003
+IV_CXOF128 = 0x0000080000CC0004
+IV_AEAD128 = 0x00001000808C0001
+
+
+def to_bits(data, nbits):
+    assert nbits <= 8 * len(data)
+    return [(data[i // 8] >> (i % 8)) & 1 for i in range(nbits)]
+
+
+def from_bits(bits):
+    out = bytearray((len(bits) + 7) // 8)
+    for i, b in enumerate(bits):
+        out[i // 8] |= b << (i % 8)
+    return bytes(out)
+
+
+def pad(bits, rate):
+    return bits + [1] + [0] * ((-len(bits) - 1) % rate)
+
+
+def blocks(bits, rate):
+    return [bits[i : i + rate] for i in range(0, len(bits), rate)]
+
+
+def word(bits):
+    return sum(b << i for i, b in enumerate(bits))
+
+
+def word_bits(w):
+    return [(w >> i) & 1 for i in range(64)]
+
+
+def xof(iv, z, msg, outbits):
+    """Ascon-Hash256, Ascon-XOF128 and, if z is not None, Ascon-CXOF128."""
+    s = [iv, 0, 0, 0, 0]
+    permute(s, 12)
+    inputs = [msg]
+    if z is not None:
+        # the length of z is a single block, with no padding
+        s[0] ^= len(z)
+        permute(s, 12)
+        inputs = [z, msg]
+    for x in inputs:
+        for blk in blocks(pad(x, 64), 64):
+            s[0] ^= word(blk)
+            permute(s, 12)
+    out = []
+    while len(out) < outbits:
+        out += word_bits(s[0])
+        permute(s, 12)
+    return out[:outbits]
+
+
+def hash256(msg):
+    return xof(IV_HASH256, None, msg, 256)
+
+
+def aead128_encrypt(key, nonce, ad, pt):
+    k0, k1 = word(key[:64]), word(key[64:])
+    s = [IV_AEAD128, k0, k1, word(nonce[:64]), word(nonce[64:])]
+    permute(s, 12)
+    s[3] ^= k0
+    s[4] ^= k1
+    if ad:
+        for blk in blocks(pad(ad, 128), 128):
+            s[0] ^= word(blk[:64])
+            s[1] ^= word(blk[64:])
+            permute(s, 8)
+    s[4] ^= 1 << 63
+    ct = []
+    padded = blocks(pad(pt, 128), 128)
+    for blk in padded[:-1]:
+        s[0] ^= word(blk[:64])
+        s[1] ^= word(blk[64:])
+        ct += word_bits(s[0]) + word_bits(s[1])
+        permute(s, 8)
+    last = padded[-1]
+    s[0] ^= word(last[:64])
+    s[1] ^= word(last[64:])
+    ct += (word_bits(s[0]) + word_bits(s[1]))[: len(pt) % 128]
+    s[2] ^= k0
+    s[3] ^= k1
+    permute(s, 12)
+    tag = word_bits(s[3] ^ k0) + word_bits(s[4] ^ k1)
+    return ct, tag
+
+
+def pattern(n, base):
+    """Like mkPattern in the Go tests."""
+    return bytes((base + i) & 0xFF for i in range(n))
+
+
+def check():
+    """Checks the model against vectors from the official KAT files."""
+    for n, want in [
+        (0, "0B3BE5850F2F6B98CAF29F8FDEA89B64A1FA70AA249B8F839BD53BAA304D92B2"),
+        (1, "0728621035AF3ED2BCA03BF6FDE900F9456F5330E4B5EE23E7F6A1E70291BC80"),
+        (8, "B88E497AE8E6FB641B87EF622EB8F2FCA0ED95383F7FFEBE167ACF1099BA764F"),
+        (100, "A4BC453C84F824F10092E8E9031799957E984A29BBAE5E84345E82F48DD71192"),
+    ]:
+        got = from_bits(hash256(to_bits(pattern(n, 0), 8 * n))).hex().upper()
+        assert got == want, (n, got)
+    key = to_bits(pattern(16, 0), 128)
+    pt, ad = pattern(15, 0), pattern(18, 0)
+    ct, tag = aead128_encrypt(key, key, to_bits(ad, 8 * len(ad)), to_bits(pt, 8 * len(pt)))
+    got = (from_bits(ct) + from_bits(tag)).hex().upper()
+    assert got == "501DFE330EC4528E8D3BC467A02391946E05C9402166B0CFB2E25844EA1277", got
+
+
+def main(w):
+    count = 0
+
+    def entry(**kv):
+        nonlocal count
+        count += 1
+        w.write("Count = %d\n" % count)
+        for k, v in kv.items():
+            if isinstance(v, bytes):
+                v = v.hex().upper()
+            w.write("%s = %s\n" % (k, v))
+        w.write("\n")
+
+    lengths = [0, 1, 3, 7, 8, 9, 13, 63, 64, 65, 127, 128, 129, 191, 200]
+    for n in lengths:
+        msg = to_bits(pattern((n + 7) // 8, 0xB5), n)
+        entry(Alg="Ascon-Hash256", Len=n, Msg=from_bits(msg), MD=from_bits(hash256(msg)))
+    for n in lengths:
+        msg = to_bits(pattern((n + 7) // 8, 0xB5), n)
+        for outbits in [1, 37, 64, 129]:
+            entry(Alg="Ascon-XOF128", Len=n, Msg=from_bits(msg), OutLen=outbits,
+                  MD=from_bits(xof(IV_XOF128, None, msg, outbits)))
+    for n in [0, 5, 64, 70]:
+        msg = to_bits(pattern((n + 7) // 8, 0xB5), n)
+        for zbits in [0, 1, 12, 64, 67, 130]:
+            z = to_bits(pattern((zbits + 7) // 8, 0xC9), zbits)
+            entry(Alg="Ascon-CXOF128", Len=n, Msg=from_bits(msg), CsLen=zbits, Cs=from_bits(z),
+                  OutLen=37, MD=from_bits(xof(IV_CXOF128, z, msg, 37)))
+    key = to_bits(pattern(16, 0x00), 128)
+    nonce = to_bits(pattern(16, 0x20), 128)
+    for ptbits in [0, 1, 7, 8, 63, 65, 127, 128, 129, 135, 260]:
+        for adbits in [0, 1, 12, 128, 131]:
+            pt = to_bits(pattern((ptbits + 7) // 8, 0x4B), ptbits)
+            ad = to_bits(pattern((adbits + 7) // 8, 0x6D), adbits)
+            ct, tag = aead128_encrypt(key, nonce, ad, pt)
+            entry(Alg="Ascon-AEAD128", Key=from_bits(key), Nonce=from_bits(nonce),
+                  PTLen=ptbits, PT=from_bits(pt), ADLen=adbits, AD=from_bits(ad),
+                  CT=from_bits(ct) + from_bits(tag))
+
+
+if __name__ == "__main__":
+    check()
+    main(sys.stdout)
@@ -0,0 +1,191 @@
+package ascon
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Tree hashing splits a message into fixed-size leaves
+// which can be hashed in parallel, like ParallelHash from NIST SP 800-185.
+//
+// Each leaf is hashed with Ascon-CXOF128, customized with "Ascon-Tree128 leaf",
+// to a HashSize-byte chaining value.
+// The digest is the first HashSize bytes of Ascon-CXOF128,
+// customized with "Ascon-Tree128 root", of
+//
+//	leafSize || cv[0] || cv[1] || ... || cv[n-1] || n || 8*len(message)
+//
+// where the integers are encoded as 64-bit little-endian numbers.
+// The last leaf may be shorter than leafSize;
+// an empty message has no leaves.
+
+const (
+	// DefaultLeafSize is the leaf size used if TreeHashOptions.LeafSize is zero.
+	DefaultLeafSize = 64 << 10
+
+	// MaxLeafSize is the largest allowed leaf size.
+	MaxLeafSize = 16 << 20
+
+	// maxTreeBuffer bounds the buffer of leafSize*workers bytes.
+	maxTreeBuffer = 64 << 20
+
+	treeLeafCustomization = "Ascon-Tree128 leaf"
+	treeRootCustomization = "Ascon-Tree128 root"
+)
+
+// TreeHashOptions configures NewTreeHash.
+// A nil *TreeHashOptions is equivalent to the zero value.
+type TreeHashOptions struct {
+	// LeafSize is the number of message bytes in each leaf.
+	// If zero, DefaultLeafSize is used.
+	// Different leaf sizes give different digests.
+	LeafSize int
+
+	// Workers is the number of leaves which are hashed at the same time.
+	// If zero, runtime.GOMAXPROCS(0) is used.
+	// Since a TreeHash buffers one leaf per worker,
+	// the number of workers is reduced if the leaves would take more than 64 MiB.
+	// The digest does not depend on the number of workers.
+	Workers int
+}
+
+// TreeHash implements the Ascon-Tree128 tree hash described above.
+// It implements the hash.Hash interface.
+//
+// Writes are buffered until there is a full leaf for each worker,
+// and then the leaves are hashed in parallel.
+type TreeHash struct {
+	leafSize int
+
+	leaf     Cxof128 // initial leaf state
+	rootInit Cxof128 // initial root state, after absorbing the leaf size
+	root     Cxof128
+
+	buf    []byte // pending message bytes; cap(buf) = leafSize*workers
+	cvs    [][HashSize]byte
+	leaves uint64 // number of leaves absorbed into root
+	length uint64 // number of bytes written
+}
+
+// NewTreeHash returns a new TreeHash.
+func NewTreeHash(opts *TreeHashOptions) (*TreeHash, error) {
+	leafSize, workers := DefaultLeafSize, runtime.GOMAXPROCS(0)
+	if opts != nil {
+		if opts.LeafSize < 0 || opts.LeafSize > MaxLeafSize {
+			return nil, fmt.Errorf("ascon: invalid leaf size %d", opts.LeafSize)
+		}
+		if opts.LeafSize > 0 {
+			leafSize = opts.LeafSize
+		}
+		if opts.Workers > 0 {
+			workers = opts.Workers
+		}
+	}
+	if workers > maxTreeBuffer/leafSize {
+		// maxTreeBuffer/MaxLeafSize is at least 1
+		workers = maxTreeBuffer / leafSize
+	}
+	h := &TreeHash{
+		leafSize: leafSize,
+		buf:      make([]byte, 0, leafSize*workers),
+		cvs:      make([][HashSize]byte, workers),
+	}
+	h.leaf.init(treeLeafCustomization)
+	h.rootInit.init(treeRootCustomization)
+	var b [8]byte
+	le64enc(b[:], uint64(leafSize))
+	h.rootInit.digest.write(b[:])
+	h.Reset()
+	return h, nil
+}
+
+func (h *TreeHash) Size() int { return HashSize }
+
+// BlockSize returns the leaf size.
+// Writes which are a multiple of the leaf size avoid copying.
+func (h *TreeHash) BlockSize() int { return h.leafSize }
+
+func (h *TreeHash) Reset() {
+	h.root = h.rootInit
+	h.buf = h.buf[:0]
+	h.leaves = 0
+	h.length = 0
+}
+
+func (h *TreeHash) Write(p []byte) (int, error) {
+	n := len(p)
+	h.length += uint64(n)
+	batch := cap(h.buf)
+	for len(p) > 0 {
+		if len(h.buf) == 0 && len(p) >= batch {
+			// hash directly from p
+			h.leaves += h.absorbLeaves(&h.root, p[:batch])
+			p = p[batch:]
+			continue
+		}
+		m := copy(h.buf[len(h.buf):batch], p)
+		h.buf = h.buf[:len(h.buf)+m]
+		p = p[m:]
+		if len(h.buf) == batch {
+			h.leaves += h.absorbLeaves(&h.root, h.buf)
+			h.buf = h.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+// Sum appends the digest to b and returns the new slice.
+// It does not change the hash state.
+func (h *TreeHash) Sum(b []byte) []byte {
+	root := h.root // copy
+	leaves := h.leaves + h.absorbLeaves(&root, h.buf)
+
+	var n [8]byte
+	le64enc(n[:], leaves)
+	root.digest.write(n[:])
+	le64enc(n[:], h.length*8)
+	root.digest.write(n[:])
+
+	var out [HashSize]byte
+	root.digest.read(out[:])
+	root.digest.wipe()
+	return append(b, out[:]...)
+}
+
+// absorbLeaves hashes data, which holds at most len(h.cvs) leaves,
+// absorbs the chaining values into root, and returns the number of leaves.
+func (h *TreeHash) absorbLeaves(root *Cxof128, data []byte) uint64 {
+	n := 0
+	var wg sync.WaitGroup
+	for len(data) > 0 {
+		m := h.leafSize
+		if len(data) < m {
+			m = len(data)
+		}
+		if len(data) > m || n > 0 {
+			wg.Add(1)
+			go func(cv *[HashSize]byte, leaf []byte) {
+				h.hashLeaf(cv, leaf)
+				wg.Done()
+			}(&h.cvs[n], data[:m])
+		} else {
+			// a single leaf doesn't need a goroutine
+			h.hashLeaf(&h.cvs[n], data[:m])
+		}
+		data = data[m:]
+		n++
+	}
+	wg.Wait()
+	for i := range h.cvs[:n] {
+		root.digest.write(h.cvs[i][:])
+	}
+	return uint64(n)
+}
+
+func (h *TreeHash) hashLeaf(cv *[HashSize]byte, leaf []byte) {
+	x := h.leaf // copy
+	x.digest.write(leaf)
+	x.digest.read(cv[:])
+	x.digest.wipe()
+}
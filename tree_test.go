@@ -0,0 +1,200 @@
+package ascon
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash"
+	"os"
+	"strconv"
+	"testing"
+)
+
+var _ hash.Hash = (*TreeHash)(nil)
+
+var treeHashTests = []struct {
+	leafSize  int
+	msgLen    int
+	hexDigest string
+}{
+	{1024, 0, "F718A4AE5306B5CBB64B2D9D3F793080FA604805001E5A6320638201554400C6"},
+	{1024, 1, "783AD1E6A5382FC1C10AB4BBFF81280EE7809B942DB26CCFB73222F36AB398C2"},
+	{1024, 1024, "70AAB16ACCBE3D6A34954C1F00FE664D3799CEC023ABBA04286E14980FB46A81"},
+	{1024, 1025, "5658800755B33C45EB2BA9D3B79F68A8DACC91B9F7A36039D5C2AF2CA4AA0781"},
+	{1024, 5000, "8923DB95B72DA23FA654D63A5D0143330A5108BD8309D1A1676DC8C6CF56814C"},
+	{8, 100, "F7EFF406E97B3CEA3AB27A723A61D5C9FFD37D954E035ADD93183766DD316BCE"},
+}
+
+func TestTreeHash(t *testing.T) {
+	for _, tt := range treeHashTests {
+		msg := mkPattern(tt.msgLen, 0x00)
+		h, err := NewTreeHash(&TreeHashOptions{LeafSize: tt.leafSize, Workers: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(msg)
+		if got := fmt.Sprintf("%X", h.Sum(nil)); got != tt.hexDigest {
+			t.Errorf("leafSize=%d msgLen=%d: got %s, want %s", tt.leafSize, tt.msgLen, got, tt.hexDigest)
+		}
+		if got, want := h.Sum(nil), refTreeHash(msg, tt.leafSize); !bytes.Equal(got, want) {
+			t.Errorf("leafSize=%d msgLen=%d: got %X, reference implementation got %X", tt.leafSize, tt.msgLen, got, want)
+		}
+	}
+}
+
+// refTreeHash is a straightforward implementation of the tree hash
+// as documented in tree.go.
+func refTreeHash(msg []byte, leafSize int) []byte {
+	root, _ := NewCxof128(treeRootCustomization)
+	var n [8]byte
+	le64enc(n[:], uint64(leafSize))
+	root.Write(n[:])
+	leaves := 0
+	for p := msg; len(p) > 0; leaves++ {
+		m := leafSize
+		if len(p) < m {
+			m = len(p)
+		}
+		leaf, _ := NewCxof128(treeLeafCustomization)
+		leaf.Write(p[:m])
+		cv := make([]byte, HashSize)
+		leaf.Read(cv)
+		root.Write(cv)
+		p = p[m:]
+	}
+	le64enc(n[:], uint64(leaves))
+	root.Write(n[:])
+	le64enc(n[:], uint64(len(msg))*8)
+	root.Write(n[:])
+	out := make([]byte, HashSize)
+	root.Read(out)
+	return out
+}
+
+func TestTreeHashWorkers(t *testing.T) {
+	const leafSize = 100
+	msg := mkPattern(2345, 0x00)
+	want := refTreeHash(msg, leafSize)
+	for _, workers := range []int{1, 2, 3, 8, 30} {
+		for _, chunk := range []int{1, 7, 100, 299, 1000, len(msg)} {
+			h, _ := NewTreeHash(&TreeHashOptions{LeafSize: leafSize, Workers: workers})
+			for p := msg; len(p) > 0; {
+				m := chunk
+				if len(p) < m {
+					m = len(p)
+				}
+				h.Write(p[:m])
+				// Sum doesn't change the state
+				if len(p) == len(msg) {
+					h.Sum(nil)
+				}
+				p = p[m:]
+			}
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("workers=%d chunk=%d: got %X, want %X", workers, chunk, got, want)
+			}
+			h.Reset()
+			h.Write(msg)
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("workers=%d after Reset: got %X, want %X", workers, got, want)
+			}
+		}
+	}
+}
+
+func TestTreeHashOptions(t *testing.T) {
+	h, err := NewTreeHash(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.BlockSize() != DefaultLeafSize {
+		t.Errorf("BlockSize() = %d, want %d", h.BlockSize(), DefaultLeafSize)
+	}
+	msg := mkPattern(100, 0x00)
+	h.Write(msg)
+	h2, _ := NewTreeHash(&TreeHashOptions{LeafSize: 64})
+	h2.Write(msg)
+	if bytes.Equal(h.Sum(nil), h2.Sum(nil)) {
+		t.Error("different leaf sizes give the same digest")
+	}
+	for _, n := range []int{-1, MaxLeafSize + 1} {
+		if _, err := NewTreeHash(&TreeHashOptions{LeafSize: n}); err == nil {
+			t.Errorf("NewTreeHash accepted leaf size %d", n)
+		}
+	}
+
+	// too many workers for the leaf size
+	h3, err := NewTreeHash(&TreeHashOptions{LeafSize: MaxLeafSize, Workers: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(h3.buf) > maxTreeBuffer || len(h3.cvs) != maxTreeBuffer/MaxLeafSize {
+		t.Errorf("buffer of %d bytes for %d workers, want at most %d bytes", cap(h3.buf), len(h3.cvs), maxTreeBuffer)
+	}
+	h3.Write(msg)
+	if got, want := h3.Sum(nil), refTreeHash(msg, MaxLeafSize); !bytes.Equal(got, want) {
+		t.Errorf("leafSize=%d: got %X, reference implementation got %X", MaxLeafSize, got, want)
+	}
+}
+
+// treeHashKatFile was generated by TestGenKatTreeHash.
+const treeHashKatFile = "testdata/ascon_tree_128_kat.txt"
+
+func TestTreeHashKat(t *testing.T) {
+	n := 0
+	readKat(t, treeHashKatFile, func(kv map[string]string) {
+		n++
+		leafSize, err := strconv.Atoi(kv["LeafSize"])
+		if err != nil {
+			t.Fatalf("Count = %s: bad LeafSize: %v", kv["Count"], err)
+		}
+		h, err := NewTreeHash(&TreeHashOptions{LeafSize: leafSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Write(unhex(kv["Msg"]))
+		checkHex(t, "Count = "+kv["Count"], h.Sum(nil), kv["MD"])
+	})
+	if n == 0 {
+		t.Errorf("no test vectors in %s", treeHashKatFile)
+	}
+}
+
+func TestGenKatTreeHash(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	f, err := os.Create("ascon_tree_128_kat.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	num := 0
+	for _, leafSize := range []int{8, 64, 1024} {
+		for i := 0; i <= 2048; i += 61 {
+			num++
+			msg := mkPattern(i, 0x00)
+			h, _ := NewTreeHash(&TreeHashOptions{LeafSize: leafSize})
+			h.Write(msg)
+			fmt.Fprintf(w, "Count = %d\n", num)
+			fmt.Fprintf(w, "LeafSize = %d\n", leafSize)
+			fmt.Fprintf(w, "Msg = %X\n", msg)
+			fmt.Fprintf(w, "MD = %X\n", h.Sum(nil))
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func BenchmarkTreeHash(b *testing.B) {
+	msg := make([]byte, 8<<20)
+	b.SetBytes(int64(len(msg)))
+	h, _ := NewTreeHash(nil)
+	out := make([]byte, 0, HashSize)
+	for i := 0; i < b.N; i++ {
+		h.Reset()
+		h.Write(msg)
+		h.Sum(out[:0])
+	}
+}
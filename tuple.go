@@ -0,0 +1,193 @@
+package ascon
+
+import "fmt"
+
+// TupleHash128 is an analogue of TupleHash from NIST SP 800-185,
+// built on Ascon-CXOF128 with the customization string "Ascon-TupleHash128".
+// It hashes a sequence of byte strings unambiguously:
+// the tuples ("ab", "c") and ("a", "bc") have different hashes.
+//
+// The input to the CXOF is
+//
+//	encode_string(S) || encode_string(X[0]) || ... || encode_string(X[n-1]) || right_encode(L)
+//
+// where S is the customization string, X[i] are the elements,
+// and L is the output length in bits, or 0 in XOF mode.
+// encode_string(X) is left_encode(8*len(X)) || X,
+// and left_encode and right_encode are as in SP 800-185.
+//
+// A nested tuple of n elements is written as a zero byte,
+// which can't begin an encoded string, followed by left_encode(n)
+// and its elements.
+type TupleHash128 struct {
+	x       Cxof128
+	initial Cxof128 // x after absorbing the customization string
+	reading bool
+}
+
+const tupleHashCustomization = "Ascon-TupleHash128"
+
+// NewTupleHash128 returns a TupleHash128 with the given customization string.
+// Unlike NewCxof128, the customization string may be any length.
+func NewTupleHash128(customizationString string) *TupleHash128 {
+	h := new(TupleHash128)
+	h.initial.init(tupleHashCustomization)
+	h.initial.digest.writeEncodedLength(len(customizationString))
+	h.initial.digest.writeString(customizationString)
+	h.Reset()
+	return h
+}
+
+// Size returns the length of the output of Sum.
+func (h *TupleHash128) Size() int { return HashSize }
+
+func (h *TupleHash128) Reset() {
+	h.x = h.initial
+	h.reading = false
+}
+
+// WriteElement adds p to the tuple as a single element.
+// It returns ErrWriteAfterRead if output has already been read.
+func (h *TupleHash128) WriteElement(p []byte) error {
+	if h.reading {
+		return ErrWriteAfterRead
+	}
+	h.x.digest.writeEncodedLength(len(p))
+	h.x.digest.write(p)
+	return nil
+}
+
+// StartTuple begins a nested tuple of n elements.
+// The next n elements written, counting nested tuples as single elements,
+// belong to the nested tuple.
+// It returns ErrWriteAfterRead if output has already been read.
+func (h *TupleHash128) StartTuple(n int) error {
+	if h.reading {
+		return ErrWriteAfterRead
+	}
+	var buf [1 + 9]byte
+	h.x.digest.write(appendLeftEncode(buf[:1], uint64(n)))
+	return nil
+}
+
+// Sum appends the HashSize-byte hash of the tuple to b
+// and returns the new slice.
+// It does not change the hash state.
+// It panics if output has already been read with Read.
+func (h *TupleHash128) Sum(b []byte) []byte {
+	if h.reading {
+		panic("ascon: Sum called after Read")
+	}
+	x := h.x // copy
+	var buf [9]byte
+	x.digest.write(appendRightEncode(buf[:0], HashSize*8))
+	var out [HashSize]byte
+	x.digest.read(out[:])
+	x.digest.wipe()
+	return append(b, out[:]...)
+}
+
+// Read reads output from the hash in XOF mode.
+// The output is unrelated to the output of Sum.
+// After the first Read, no more elements may be written.
+func (h *TupleHash128) Read(p []byte) (int, error) {
+	if !h.reading {
+		var buf [9]byte
+		h.x.digest.write(appendRightEncode(buf[:0], 0))
+		h.reading = true
+	}
+	h.x.digest.read(p)
+	return len(p), nil
+}
+
+// SumTuple128 returns the TupleHash128 hash of elems
+// with the given customization string.
+func SumTuple128(customizationString string, elems ...[]byte) [HashSize]byte {
+	h := NewTupleHash128(customizationString)
+	for _, e := range elems {
+		h.WriteElement(e)
+	}
+	var out [HashSize]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// Tuple is a tuple for HashTuple.
+// Each element must be a []byte, a string, or a nested Tuple.
+type Tuple []interface{}
+
+// HashTuple returns the TupleHash128 hash of t
+// with the given customization string.
+// Strings are hashed like the equivalent []byte,
+// and nested Tuples are written with StartTuple.
+// It returns an error if t contains an element of any other type.
+func HashTuple(customizationString string, t Tuple) ([HashSize]byte, error) {
+	var out [HashSize]byte
+	h := NewTupleHash128(customizationString)
+	if err := h.writeTuple(t, false); err != nil {
+		return out, err
+	}
+	h.Sum(out[:0])
+	return out, nil
+}
+
+// writeTuple writes the elements of t,
+// preceded by StartTuple if nested is true.
+func (h *TupleHash128) writeTuple(t Tuple, nested bool) error {
+	if nested {
+		h.StartTuple(len(t))
+	}
+	for i, e := range t {
+		switch e := e.(type) {
+		case []byte:
+			h.WriteElement(e)
+		case string:
+			h.x.digest.writeEncodedLength(len(e))
+			h.x.digest.writeString(e)
+		case Tuple:
+			if err := h.writeTuple(e, true); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("ascon: unsupported tuple element %d of type %T", i, e)
+		}
+	}
+	return nil
+}
+
+// writeEncodedLength absorbs left_encode(8*n), the prefix of an encoded string of n bytes.
+func (d *digest) writeEncodedLength(n int) {
+	var buf [9]byte
+	d.write(appendLeftEncode(buf[:0], uint64(n)*8))
+}
+
+// appendLeftEncode appends left_encode(x) from NIST SP 800-185 to b:
+// the length in bytes of the big-endian encoding of x,
+// followed by that encoding, with at least one byte.
+func appendLeftEncode(b []byte, x uint64) []byte {
+	n := encodedLen(x)
+	b = append(b, byte(n))
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(x>>(8*uint(i))))
+	}
+	return b
+}
+
+// appendRightEncode appends right_encode(x) from NIST SP 800-185 to b,
+// which is like left_encode but with the length at the end.
+func appendRightEncode(b []byte, x uint64) []byte {
+	n := encodedLen(x)
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(x>>(8*uint(i))))
+	}
+	return append(b, byte(n))
+}
+
+// encodedLen returns the number of bytes needed to encode x, at least 1.
+func encodedLen(x uint64) int {
+	n := 1
+	for x >>= 8; x != 0; x >>= 8 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,126 @@
+package ascon
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestLeftRightEncode(t *testing.T) {
+	tests := []struct {
+		x           uint64
+		left, right string
+	}{
+		{0, "0100", "0001"},
+		{1, "0101", "0101"},
+		{255, "01FF", "FF01"},
+		{256, "020100", "010002"},
+		{1<<64 - 1, "08FFFFFFFFFFFFFFFF", "FFFFFFFFFFFFFFFF08"},
+	}
+	for _, tt := range tests {
+		checkHex(t, fmt.Sprintf("left_encode(%d)", tt.x), appendLeftEncode(nil, tt.x), tt.left)
+		checkHex(t, fmt.Sprintf("right_encode(%d)", tt.x), appendRightEncode(nil, tt.x), tt.right)
+	}
+}
+
+var tupleHashTests = []struct {
+	custom string
+	elems  []string
+	want   string
+}{
+	{"", nil, "9D78211271EE84EEBE473DD621F4CB8E16D7E08A71DF3238377CA457F214E71D"},
+	{"", []string{""}, "AEE025AEA8F33C4FD6044A2568C509B9CC977369F7A88FCD58D62A16A11A41F0"},
+	{"", []string{"ab", "c"}, "06267720F5E342E34FF816334A23DE25E5AD22D830ED2FE3DF835BFEBBB346DE"},
+	{"", []string{"a", "bc"}, "18D7DC0EF5DADDD3A1EFD59BDB9E704524349A6AB9DECB0E8FBDCA909FE0B3E4"},
+	{"My Tuple App", []string{"ab", "c"}, "0C754C577F16862C5A7B97D9513F7E1D66B1D988E2F93CD75DCC317C19BD82CF"},
+}
+
+func TestTupleHash(t *testing.T) {
+	for _, tt := range tupleHashTests {
+		var elems [][]byte
+		for _, e := range tt.elems {
+			elems = append(elems, []byte(e))
+		}
+		got := SumTuple128(tt.custom, elems...)
+		checkHex(t, fmt.Sprintf("%q %q", tt.custom, tt.elems), got[:], tt.want)
+
+		// compare against the definition
+		x, _ := NewCxof128(tupleHashCustomization)
+		x.Write(appendLeftEncode(nil, uint64(len(tt.custom))*8))
+		x.Write([]byte(tt.custom))
+		for _, e := range elems {
+			x.Write(appendLeftEncode(nil, uint64(len(e))*8))
+			x.Write(e)
+		}
+		x.Write(appendRightEncode(nil, HashSize*8))
+		want := make([]byte, HashSize)
+		x.Read(want)
+		if !bytes.Equal(got[:], want) {
+			t.Errorf("%q %q: got %X, definition gives %X", tt.custom, tt.elems, got, want)
+		}
+	}
+}
+
+func TestTupleHashXOF(t *testing.T) {
+	h := NewTupleHash128("")
+	h.WriteElement([]byte("abc"))
+	sum := h.Sum(nil)
+	out := make([]byte, 100)
+	h.Read(out[:10])
+	h.Read(out[10:])
+	checkHex(t, "XOF", out[:HashSize], "EB89610F3C6CF98ABCED44F52829D2EEDD95F296D93CD134D70C7496EDE5F152")
+	if bytes.Equal(out[:HashSize], sum) {
+		t.Error("XOF output equals Sum")
+	}
+	if err := h.WriteElement(nil); err != ErrWriteAfterRead {
+		t.Errorf("WriteElement after Read: got %v, want ErrWriteAfterRead", err)
+	}
+	if err := h.StartTuple(1); err != ErrWriteAfterRead {
+		t.Errorf("StartTuple after Read: got %v, want ErrWriteAfterRead", err)
+	}
+	h.Reset()
+	h.WriteElement([]byte("abc"))
+	if got := h.Sum(nil); !bytes.Equal(got, sum) {
+		t.Errorf("after Reset: got %X, want %X", got, sum)
+	}
+}
+
+func TestHashTuple(t *testing.T) {
+	flat := SumTuple128("c", []byte("a"), []byte("b"))
+	got, err := HashTuple("c", Tuple{[]byte("a"), "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != flat {
+		t.Errorf("HashTuple of a flat tuple = %X, want %X", got, flat)
+	}
+
+	// all distinct
+	tuples := []Tuple{
+		{"a", "b"},
+		{Tuple{"a", "b"}},
+		{Tuple{"a"}, "b"},
+		{"a", Tuple{"b"}},
+		{Tuple{}, "a", "b"},
+		{"a", "b", Tuple{}},
+		{Tuple{Tuple{"a", "b"}}},
+		{"\x00\x01\x02", "b"},
+	}
+	seen := map[[HashSize]byte]int{}
+	for i, tt := range tuples {
+		sum, err := HashTuple("c", tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j, dup := seen[sum]; dup {
+			t.Errorf("tuples %d and %d have the same hash", j, i)
+		}
+		seen[sum] = i
+	}
+	sum, _ := HashTuple("c", Tuple{Tuple{"a", "b"}, "c"})
+	checkHex(t, "nested", sum[:], "2D998C3BDE728C5DFDC49C7745C24E526381525910C139388463FA34C45A3F1B")
+
+	if _, err := HashTuple("", Tuple{"a", 1}); err == nil {
+		t.Error("HashTuple accepted an int")
+	}
+}
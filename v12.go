@@ -0,0 +1,296 @@
+package ascon
+
+// Hash functions from Ascon v1.2, the version of Ascon submitted to the
+// NIST lightweight cryptography competition.
+// https://ascon.iaik.tugraz.at/
+//
+// Ascon v1.2 differs from NIST.SP.800-232 in its initialization vectors
+// and in loading bytes into the state in big-endian order,
+// and its hashes pad with 0x80 rather than 0x01.
+// The outputs are not compatible.
+
+// Initialization vectors:
+// IV = k || r || a || a-b || h, with k = 0, r = 64, a = 12
+const (
+	ivHashV12 = 0x00_40_0c_00_00000100 // b = 12, h = 256
+	ivXofV12  = 0x00_40_0c_00_00000000 // b = 12, h = 0
+	ivHasha   = 0x00_40_0c_04_00000100 // b = 8, h = 256
+	ivXofa    = 0x00_40_0c_04_00000000 // b = 8, h = 0
+)
+
+// digestV12 is like digest, but for the Ascon v1.2 hashes.
+// It absorbs and squeezes with roundsB rounds of the permutation.
+type digestV12 struct {
+	s       state
+	buf     [8]byte
+	len     uint8 // number of bytes in buf
+	roundsB uint8
+
+	doneWriting bool
+}
+
+func (d *digestV12) reset(iv state, roundsB uint8) {
+	d.s = iv
+	d.buf = [8]byte{}
+	d.len = 0
+	d.roundsB = roundsB
+	d.doneWriting = false
+}
+
+func (d *digestV12) write(b []byte) {
+	if d.doneWriting {
+		panic("ascon: Write called after Read")
+	}
+	const bs = BlockSize
+	if d.len > 0 {
+		n := copy(d.buf[d.len:], b)
+		d.len += uint8(n)
+		b = b[n:]
+		if d.len < bs {
+			return
+		}
+		d.s[0] ^= be64dec(d.buf[:])
+		d.s.rounds(uint(d.roundsB))
+		d.len = 0
+	}
+	for len(b) >= bs {
+		d.s[0] ^= be64dec(b)
+		d.s.rounds(uint(d.roundsB))
+		b = b[bs:]
+	}
+	d.len = uint8(copy(d.buf[:], b))
+}
+
+// finish pads and absorbs the last block
+// and applies the final 12-round permutation.
+func (d *digestV12) finish() {
+	for i := d.len; i < BlockSize; i++ {
+		d.buf[i] = 0
+	}
+	d.buf[d.len] = 0x80
+	d.s[0] ^= be64dec(d.buf[:])
+	d.s.rounds(12)
+	d.len = 0
+}
+
+func (d *digestV12) sum(b []byte) []byte {
+	d0 := *d
+	d0.finish()
+	for i := 0; i < HashSize/8; i++ {
+		if i != 0 {
+			d0.s.rounds(uint(d0.roundsB))
+		}
+		b = be64append(b, d0.s[0])
+	}
+	d0.s.wipe()
+	return b
+}
+
+// read reads len(p) bytes of output.
+// Like digest.read, d.len is the number of bytes of d.buf already read,
+// with 8 meaning that the next block hasn't been squeezed yet.
+func (d *digestV12) read(p []byte) {
+	if !d.doneWriting {
+		d.finish()
+		be64enc(d.buf[:], d.s[0])
+		d.doneWriting = true
+	}
+	for len(p) > 0 {
+		if d.len == BlockSize {
+			d.s.rounds(uint(d.roundsB))
+			be64enc(d.buf[:], d.s[0])
+			d.len = 0
+		}
+		n := copy(p, d.buf[d.len:])
+		d.len += uint8(n)
+		p = p[n:]
+	}
+}
+
+// HashV12 provides an implementation of Ascon-Hash from Ascon v1.2,
+// the predecessor of Hash256, for checking digests made before NIST.SP.800-232.
+// It implements the hash.Hash interface.
+// The zero value is ready to use.
+type HashV12 struct {
+	d           digestV12
+	initialized bool
+}
+
+func NewHashV12() *HashV12 {
+	h := new(HashV12)
+	h.Reset()
+	return h
+}
+
+func (h *HashV12) Size() int      { return HashSize }
+func (h *HashV12) BlockSize() int { return BlockSize }
+
+func (h *HashV12) Reset() {
+	h.d.reset(state{0xee9398aadb67f03d, 0x8bb21831c60f1002, 0xb48a92db98d5da62, 0x43189921b8f8e3e8, 0x348fa5c9d525e140}, 12)
+	h.initialized = true
+}
+
+// Clone returns a new copy of h.
+func (h *HashV12) Clone() *HashV12 {
+	new := *h
+	return &new
+}
+
+func (h *HashV12) Write(p []byte) (int, error) {
+	if !h.initialized {
+		h.Reset()
+	}
+	h.d.write(p)
+	return len(p), nil
+}
+
+// Sum appends a message digest to b and returns the new slice.
+// Does not modify the hash state.
+func (h *HashV12) Sum(b []byte) []byte {
+	if !h.initialized {
+		h.Reset()
+	}
+	return h.d.sum(b)
+}
+
+// XofV12 provides an implementation of the Ascon-Xof arbitrary-length hash
+// from Ascon v1.2, the predecessor of Xof128.
+// The zero value is ready to use.
+type XofV12 struct {
+	d           digestV12
+	initialized bool
+}
+
+func NewXofV12() *XofV12 {
+	x := new(XofV12)
+	x.Reset()
+	return x
+}
+
+func (x *XofV12) BlockSize() int { return BlockSize }
+
+func (x *XofV12) Reset() {
+	x.d.reset(state{0xb57e273b814cd416, 0x2b51042562ae2420, 0x66a3a7768ddf2218, 0x5aad0a7a8153650c, 0x4f3e0e32539493b6}, 12)
+	x.initialized = true
+}
+
+// Clone returns a new copy of x.
+func (x *XofV12) Clone() *XofV12 {
+	new := *x
+	return &new
+}
+
+// Write absorbs more data into the hash state.
+// It returns ErrWriteAfterRead if output has already been read.
+func (x *XofV12) Write(p []byte) (int, error) {
+	if !x.initialized {
+		x.Reset()
+	}
+	if x.d.doneWriting {
+		return 0, ErrWriteAfterRead
+	}
+	x.d.write(p)
+	return len(p), nil
+}
+
+func (x *XofV12) Read(p []byte) (int, error) {
+	if !x.initialized {
+		x.Reset()
+	}
+	x.d.read(p)
+	return len(p), nil
+}
+
+// Hasha provides an implementation of Ascon-Hasha from Ascon v1.2,
+// which absorbs with 8 rounds of the permutation instead of 12.
+// It implements the hash.Hash interface.
+// The zero value is ready to use.
+type Hasha struct {
+	d           digestV12
+	initialized bool
+}
+
+func NewHasha() *Hasha {
+	h := new(Hasha)
+	h.Reset()
+	return h
+}
+
+func (h *Hasha) Size() int      { return HashSize }
+func (h *Hasha) BlockSize() int { return BlockSize }
+
+func (h *Hasha) Reset() {
+	h.d.reset(state{0x01470194fc6528a6, 0x738ec38ac0adffa7, 0x2ec8e3296c76384c, 0xd6f6a54d7f52377d, 0xa13c42a223be8d87}, 8)
+	h.initialized = true
+}
+
+// Clone returns a new copy of h.
+func (h *Hasha) Clone() *Hasha {
+	new := *h
+	return &new
+}
+
+func (h *Hasha) Write(p []byte) (int, error) {
+	if !h.initialized {
+		h.Reset()
+	}
+	h.d.write(p)
+	return len(p), nil
+}
+
+// Sum appends a message digest to b and returns the new slice.
+// Does not modify the hash state.
+func (h *Hasha) Sum(b []byte) []byte {
+	if !h.initialized {
+		h.Reset()
+	}
+	return h.d.sum(b)
+}
+
+// Xofa provides an implementation of the Ascon-Xofa arbitrary-length hash
+// from Ascon v1.2, which absorbs with 8 rounds of the permutation instead of 12.
+// The zero value is ready to use.
+type Xofa struct {
+	d           digestV12
+	initialized bool
+}
+
+func NewXofa() *Xofa {
+	x := new(Xofa)
+	x.Reset()
+	return x
+}
+
+func (x *Xofa) BlockSize() int { return BlockSize }
+
+func (x *Xofa) Reset() {
+	x.d.reset(state{0x44906568b77b9832, 0xcd8d6cae53455532, 0xf7b5212756422129, 0x246885e1de0d225b, 0xa8cb5ce33449973f}, 8)
+	x.initialized = true
+}
+
+// Clone returns a new copy of x.
+func (x *Xofa) Clone() *Xofa {
+	new := *x
+	return &new
+}
+
+// Write absorbs more data into the hash state.
+// It returns ErrWriteAfterRead if output has already been read.
+func (x *Xofa) Write(p []byte) (int, error) {
+	if !x.initialized {
+		x.Reset()
+	}
+	if x.d.doneWriting {
+		return 0, ErrWriteAfterRead
+	}
+	x.d.write(p)
+	return len(p), nil
+}
+
+func (x *Xofa) Read(p []byte) (int, error) {
+	if !x.initialized {
+		x.Reset()
+	}
+	x.d.read(p)
+	return len(p), nil
+}
@@ -0,0 +1,293 @@
+package ascon
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	_ hash.Hash = (*HashV12)(nil)
+	_ hash.Hash = (*Hasha)(nil)
+	_ XOF       = (*XofV12)(nil)
+	_ XOF       = (*Xofa)(nil)
+)
+
+var hashV12Tests = []struct {
+	msgLen    int
+	hexDigest string
+}{
+	{0, "7346BC14F036E87AE03D0997913088F5F68411434B3CF8B54FA796A80D251F91"},
+	{1, "8DD446ADA58A7740ECF56EB638EF775F7D5C0FD5F0C2BBBDFDEC29609D3C43A2"},
+	{8, "F4C6A44B29915D3D57CF928A18EC6226BB8DD6C1136ACD24965F7E7780CD69CF"},
+	{100, "809CFCD3619777D73B162109EFCE633B272C8AFF18578D0169CB99F4783D136E"},
+}
+
+func TestHashV12(t *testing.T) {
+	for _, tt := range hashV12Tests {
+		h := NewHashV12()
+		h.Write(mkPattern(tt.msgLen, 0x00))
+		if got := fmt.Sprintf("%X", h.Sum(nil)); got != tt.hexDigest {
+			t.Errorf("msgLen=%d: got %s, want %s", tt.msgLen, got, tt.hexDigest)
+		}
+	}
+}
+
+var xofV12Tests = []struct {
+	msgLen    int
+	hexDigest string
+}{
+	{0, "5D4CBDE6350EA4C174BD65B5B332F8408F99740B81AA02735EAEFBCF0BA0339E"},
+	{1, "B2EDBB27AC8397A55BC83D137C151DE9EDE048338FE907F0D3629E717846FEDC"},
+	{8, "18427D2D29DF1E0202649F032F2080363FEC5DE72ECAE11B4F98CCC75843E7CC"},
+	{100, "E7EDC6746AE8D804AAC1D2C66719E45529D8115762C04540F0D2FE4AC7DFD658"},
+}
+
+func TestXofV12(t *testing.T) {
+	for _, tt := range xofV12Tests {
+		x := NewXofV12()
+		x.Write(mkPattern(tt.msgLen, 0x00))
+		out := make([]byte, HashSize)
+		x.Read(out)
+		if got := fmt.Sprintf("%X", out); got != tt.hexDigest {
+			t.Errorf("msgLen=%d: got %s, want %s", tt.msgLen, got, tt.hexDigest)
+		}
+	}
+}
+
+var xofaTests = []struct {
+	msgLen    int
+	hexDigest string
+}{
+	{0, "7C10DFFD6BB03BE262D72FBE1B0F530013C6C4EADAABDE278D6F29D579E3908D"},
+	{1, "965445C46C8E9B948EDFEF7B5879E06AB5F023770EA892FA4B54525008467EA3"},
+	{8, "91C72F6273B6ED444BF560F2FAC99E8FEDDDF30162688B86553EB57F1C98C20E"},
+	{100, "6001581C271632A01D40A56FED6E6C4BEA95D4E82412C65E2C95F622200CEA2C"},
+}
+
+func TestXofa(t *testing.T) {
+	for _, tt := range xofaTests {
+		x := NewXofa()
+		x.Write(mkPattern(tt.msgLen, 0x00))
+		out := make([]byte, HashSize)
+		x.Read(out)
+		if got := fmt.Sprintf("%X", out); got != tt.hexDigest {
+			t.Errorf("msgLen=%d: got %s, want %s", tt.msgLen, got, tt.hexDigest)
+		}
+	}
+}
+
+func TestXofaChunks(t *testing.T) {
+	init := NewXofa()
+	init.Write([]byte("abc"))
+	expected := make([]byte, 100)
+	init.Clone().Read(expected)
+	for _, n := range []int{1, 3, 7, 8, 9, 16, 17} {
+		x := init.Clone()
+		out := make([]byte, len(expected))
+		for i := 0; i < len(out); i += n {
+			end := i + n
+			if end > len(out) {
+				end = len(out)
+			}
+			x.Read(out[i:end])
+		}
+		if !bytes.Equal(out, expected) {
+			t.Errorf("reads of %d bytes: got %X, want %X", n, out, expected)
+		}
+	}
+	if _, err := init.Clone().Write(nil); err != nil {
+		t.Errorf("Write before Read: %v", err)
+	}
+	x := init.Clone()
+	x.Read(expected[:1])
+	if _, err := x.Write(nil); err != ErrWriteAfterRead {
+		t.Errorf("Write after Read: got %v, want ErrWriteAfterRead", err)
+	}
+}
+
+func TestV12ZeroValue(t *testing.T) {
+	msg := []byte("abc")
+	var h Hasha
+	h.Write(msg)
+	h2 := NewHasha()
+	h2.Write(msg)
+	if got, want := h.Sum(nil), h2.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("Hasha: got %X, want %X", got, want)
+	}
+	var x Xofa
+	x2 := NewXofa()
+	got, want := make([]byte, 16), make([]byte, 16)
+	x.Read(got)
+	x2.Read(want)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Xofa: got %X, want %X", got, want)
+	}
+
+	var hv HashV12
+	hv.Write(msg)
+	hv2 := NewHashV12()
+	hv2.Write(msg)
+	if got, want := hv.Sum(nil), hv2.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("HashV12: got %X, want %X", got, want)
+	}
+	var xv XofV12
+	xv2 := NewXofV12()
+	xv.Read(got)
+	xv2.Read(want)
+	if !bytes.Equal(got, want) {
+		t.Errorf("XofV12: got %X, want %X", got, want)
+	}
+}
+
+// KAT files from the Ascon v1.2 submission package
+// (crypto_hash/asconhashv12, asconxofv12, asconhashav12 and asconxofav12),
+// downloaded by getjson.sh.
+var (
+	hashV12KatFile = filepath.Join("testdata", "asconhashv12", "LWC_HASH_KAT_256.txt")
+	xofV12KatFile  = filepath.Join("testdata", "asconxofv12", "LWC_HASH_KAT_256.txt")
+	hashaKatFile   = filepath.Join("testdata", "asconhashav12", "LWC_HASH_KAT_256.txt")
+	xofaKatFile    = filepath.Join("testdata", "asconxofav12", "LWC_HASH_KAT_256.txt")
+)
+
+// lwcHashKatCount is the number of entries in an LWC hash KAT file,
+// for messages of 0 to 1024 bytes.
+const lwcHashKatCount = 1025
+
+func TestHashV12Kat(t *testing.T) {
+	if n := testHashKatV12(t, hashV12KatFile, NewHashV12()); n != lwcHashKatCount {
+		t.Errorf("got %d test vectors from %s, want %d", n, hashV12KatFile, lwcHashKatCount)
+	}
+}
+
+func TestXofV12Kat(t *testing.T) {
+	if n := testXofKatV12(t, xofV12KatFile, NewXofV12()); n != lwcHashKatCount {
+		t.Errorf("got %d test vectors from %s, want %d", n, xofV12KatFile, lwcHashKatCount)
+	}
+}
+
+func TestHashaKat(t *testing.T) {
+	if n := testHashKatV12(t, hashaKatFile, NewHasha()); n != lwcHashKatCount {
+		t.Errorf("got %d test vectors from %s, want %d", n, hashaKatFile, lwcHashKatCount)
+	}
+}
+
+func TestXofaKat(t *testing.T) {
+	if n := testXofKatV12(t, xofaKatFile, NewXofa()); n != lwcHashKatCount {
+		t.Errorf("got %d test vectors from %s, want %d", n, xofaKatFile, lwcHashKatCount)
+	}
+}
+
+// testHashKatV12 checks the entries of a hash KAT file
+// and returns how many there were.
+func testHashKatV12(t *testing.T, name string, h hash.Hash) int {
+	n := 0
+	readKat(t, name, func(kv map[string]string) {
+		n++
+		h.Reset()
+		h.Write(unhex(kv["Msg"]))
+		if got, want := h.Sum(nil), unhex(kv["MD"]); !bytes.Equal(got, want) {
+			t.Errorf("Count = %s: got %X, want %X", kv["Count"], got, want)
+		}
+	})
+	return n
+}
+
+// testXofKatV12 is like testHashKatV12, for an XOF.
+func testXofKatV12(t *testing.T, name string, x XOF) int {
+	n := 0
+	readKat(t, name, func(kv map[string]string) {
+		n++
+		x.Reset()
+		x.Write(unhex(kv["Msg"]))
+		want := unhex(kv["MD"])
+		got := make([]byte, len(want))
+		x.Read(got)
+		if !bytes.Equal(got, want) {
+			t.Errorf("Count = %s: got %X, want %X", kv["Count"], got, want)
+		}
+	})
+	return n
+}
+
+func TestGenKatHashV12(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	genKatV12(t, "ascon_hashv12_kat.txt", func(msg []byte) []byte {
+		h := NewHashV12()
+		h.Write(msg)
+		return h.Sum(nil)
+	})
+}
+
+func TestGenKatXofV12(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	genKatV12(t, "ascon_xofv12_kat.txt", func(msg []byte) []byte {
+		x := NewXofV12()
+		x.Write(msg)
+		out := make([]byte, HashSize)
+		x.Read(out)
+		return out
+	})
+}
+
+func TestGenKatHasha(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	genKatV12(t, "ascon_hasha_kat.txt", func(msg []byte) []byte {
+		h := NewHasha()
+		h.Write(msg)
+		return h.Sum(nil)
+	})
+}
+
+func TestGenKatXofa(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	genKatV12(t, "ascon_xofa_kat.txt", func(msg []byte) []byte {
+		x := NewXofa()
+		x.Write(msg)
+		out := make([]byte, HashSize)
+		x.Read(out)
+		return out
+	})
+}
+
+// genKatV12 writes a hash KAT file in the format of the v1.2 submission package.
+func genKatV12(t *testing.T, name string, sum func([]byte) []byte) {
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	for i := 0; i <= 1024; i++ {
+		msg := mkPattern(i, 0x00)
+		fmt.Fprintf(w, "Count = %d\n", i+1)
+		fmt.Fprintf(w, "Msg = %X\n", msg)
+		fmt.Fprintf(w, "MD = %X\n", sum(msg))
+		fmt.Fprintln(w)
+	}
+}
+
+func BenchmarkXofa(b *testing.B) {
+	out := make([]byte, HashSize)
+	msg := make([]byte, 1024)
+	b.SetBytes(int64(len(msg)))
+	x := NewXofa()
+	for i := 0; i < b.N; i++ {
+		x.Reset()
+		x.Write(msg)
+		x.Read(out)
+	}
+}
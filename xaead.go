@@ -0,0 +1,91 @@
+package ascon
+
+import "fmt"
+
+// XNonceSize is the size of the extended nonce used by XAEAD128.
+const XNonceSize = 256 / 8
+
+// XAEAD128 is Ascon-AEAD128 with an extended, 256-bit nonce,
+// long enough to be chosen at random without fear of collisions.
+// It implements the crypto/cipher.AEAD interface.
+//
+// For each message, the first half of the nonce is hashed together with the key,
+// using Ascon-CXOF128, to derive a subkey.
+// The message is then sealed with Ascon-AEAD128 under the subkey,
+// using the second half of the nonce.
+// Each subkey only ever protects a small number of messages,
+// so the per-key data limit of Ascon-AEAD128 applies per nonce prefix
+// rather than to the key as a whole.
+type XAEAD128 struct {
+	kdf Cxof128 // keyed with the key; never finalized
+}
+
+const xaeadCustomization = "Ascon-XAEAD128"
+
+func NewXAEAD128(key []byte) (*XAEAD128, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	a := new(XAEAD128)
+	a.kdf = newKeyedCxof(xaeadCustomization, key)
+	return a, nil
+}
+
+func (*XAEAD128) NonceSize() int { return XNonceSize }
+func (*XAEAD128) Overhead() int  { return TagSize }
+
+// Seal encrypts and authenticates a plaintext
+// and appends ciphertext to dst, returning the appended slice.
+func (a *XAEAD128) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != XNonceSize {
+		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
+	}
+	var sub AEAD128
+	a.subkey(&sub, nonce)
+	dst = sub.Seal(dst, nonce[XNonceSize-NonceSize:], plaintext, additionalData)
+	sub.Destroy()
+	return dst
+}
+
+// TrySeal is like Seal, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+// In that case dst is returned unchanged.
+func (a *XAEAD128) TrySeal(dst, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != XNonceSize {
+		return dst, ErrNonceSize
+	}
+	return a.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// TryOpen is like Open, but returns ErrNonceSize instead of panicking
+// if the nonce is the wrong size.
+func (a *XAEAD128) TryOpen(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != XNonceSize {
+		return dst, ErrNonceSize
+	}
+	return a.Open(dst, nonce, ciphertext, additionalData)
+}
+
+func (a *XAEAD128) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != XNonceSize {
+		panic(fmt.Sprintf("ascon: bad nonce (len %d)", len(nonce)))
+	}
+	var sub AEAD128
+	a.subkey(&sub, nonce)
+	dst, err := sub.Open(dst, nonce[XNonceSize-NonceSize:], ciphertext, additionalData)
+	sub.Destroy()
+	return dst, err
+}
+
+// subkey derives the Ascon-AEAD128 key for the given extended nonce.
+func (a *XAEAD128) subkey(sub *AEAD128, nonce []byte) {
+	x := a.kdf // copy
+	x.digest.write(nonce[:XNonceSize-NonceSize])
+	var key [KeySize]byte
+	x.digest.read(key[:])
+	x.digest.wipe()
+	sub.SetKey(key[:])
+	for i := range key {
+		key[i] = 0
+	}
+}
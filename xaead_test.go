@@ -0,0 +1,129 @@
+package ascon
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"os"
+	"testing"
+)
+
+var _ cipher.AEAD = (*XAEAD128)(nil)
+
+// Test vectors in the format of xaeadKatFile.
+// Key, nonce, plaintext and additional data follow the same pattern as TestGenKatXAEAD128.
+var xaeadTests = []struct {
+	msgLen, adLen int
+	hexCiphertext string
+}{
+	{0, 0, "A40CFA2AB1102719E33F60E9AEE90D07"},
+	{0, 1, "05250895D8DEB79B8131F7809D430FB8"},
+	{1, 0, "43E8796DA4B1D10B74DAF04EAFCE5C643F"},
+	{15, 16, "207D8A68CBE0CF39D86715D195DFEDAF8681776F87BF6AF167D45259FCD720"},
+	{16, 15, "0F8E06EDBFCCB368BB12CA86A7F55E34596EBD10E92EFFBCEA233EB74A7D4723"},
+	{32, 32, "83D8BB8589F80AE2D47C49B06D2E466E3A6D3ECF6B1E1CFB1F3A4E2B76CCDDD416134705235D1F4104B18A9D8D05749C"},
+}
+
+func mkPattern(n int, base byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = base + byte(i%256)
+	}
+	return b
+}
+
+func TestXAEAD(t *testing.T) {
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(XNonceSize, 0x10)
+	a, err := NewXAEAD128(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range xaeadTests {
+		msg := mkPattern(tt.msgLen, 0x30)
+		ad := mkPattern(tt.adLen, 0x40)
+		c := a.Seal(nil, nonce, msg, ad)
+		got := fmt.Sprintf("%X", c)
+		if got != tt.hexCiphertext {
+			t.Errorf("msgLen=%d adLen=%d: got %s, want %s", tt.msgLen, tt.adLen, got, tt.hexCiphertext)
+		}
+		p, err := a.Open(nil, nonce, c, ad)
+		if err != nil {
+			t.Errorf("msgLen=%d adLen=%d: decryption failed: %v", tt.msgLen, tt.adLen, err)
+		} else if !bytes.Equal(p, msg) {
+			t.Errorf("msgLen=%d adLen=%d: got %X, want %X", tt.msgLen, tt.adLen, p, msg)
+		}
+	}
+}
+
+func TestXAEADNonce(t *testing.T) {
+	a, _ := NewXAEAD128(make([]byte, KeySize))
+	msg := []byte("attack at dawn")
+	nonce := make([]byte, XNonceSize)
+	c := a.Seal(nil, nonce, msg, nil)
+	// Changing either half of the nonce must change the ciphertext
+	for _, i := range []int{0, XNonceSize - NonceSize - 1, XNonceSize - NonceSize, XNonceSize - 1} {
+		nonce[i] ^= 1
+		if c2 := a.Seal(nil, nonce, msg, nil); bytes.Equal(c, c2) {
+			t.Errorf("flipping nonce byte %d did not change the ciphertext", i)
+		}
+		if _, err := a.Open(nil, nonce, c, nil); err == nil {
+			t.Errorf("Open succeeded after flipping nonce byte %d", i)
+		}
+		nonce[i] ^= 1
+	}
+}
+
+// xaeadKatFile was generated by TestGenKatXAEAD128.
+const xaeadKatFile = "testdata/ascon_xaead_128_kat.txt"
+
+func TestXAEADKat(t *testing.T) {
+	n := 0
+	readKat(t, xaeadKatFile, func(kv map[string]string) {
+		n++
+		a, err := NewXAEAD128(unhex(kv["Key"]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce, pt, ad := unhex(kv["Nonce"]), unhex(kv["PT"]), unhex(kv["AD"])
+		checkHex(t, "Count = "+kv["Count"], a.Seal(nil, nonce, pt, ad), kv["CT"])
+		if got, err := a.Open(nil, nonce, unhex(kv["CT"]), ad); err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("Count = %s: Open = %X, %v; want %X", kv["Count"], got, err, pt)
+		}
+	})
+	if n != 33*33 {
+		t.Errorf("got %d test vectors from %s, want %d", n, xaeadKatFile, 33*33)
+	}
+}
+
+func TestGenKatXAEAD128(t *testing.T) {
+	if !*genkat {
+		t.Skip("skipping without -genkat flag")
+	}
+	f, err := os.Create("ascon_xaead_128_kat.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	defer w.Flush()
+	num := 0
+	key := mkPattern(KeySize, 0x00)
+	nonce := mkPattern(XNonceSize, 0x10)
+	a, _ := NewXAEAD128(key)
+	for i := 0; i <= 32; i++ {
+		for j := 0; j <= 32; j++ {
+			num++
+			msg := mkPattern(i, 0x30)
+			ad := mkPattern(j, 0x40)
+			fmt.Fprintf(w, "Count = %d\n", num)
+			fmt.Fprintf(w, "Key = %X\n", key)
+			fmt.Fprintf(w, "Nonce = %X\n", nonce)
+			fmt.Fprintf(w, "PT = %X\n", msg)
+			fmt.Fprintf(w, "AD = %X\n", ad)
+			fmt.Fprintf(w, "CT = %X\n", a.Seal(nil, nonce, msg, ad))
+			fmt.Fprintln(w)
+		}
+	}
+}